@@ -0,0 +1,236 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package zcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/errs"
+	"github.com/zlyuancn/zcache/wrap_call"
+)
+
+// 加载缓存未命中的数据并写入缓存, 返回数据和错误的数量和请求数量一致
+//
+// 使用批量加载器的query会按批量加载器分组后批量加载, 其它的query会逐个加载
+func (c *Cache) loadMisses(ctx context.Context, queries []core.IQuery) ([][]byte, []error) {
+	buffs := make([][]byte, len(queries))
+	es := make([]error, len(queries))
+
+	// 按批量加载器分组
+	groups := make(map[core.IBatchLoader][]int)
+	var loaders []core.IBatchLoader
+	for i, q := range queries {
+		l, ok := c.findLoader(q).(core.IBatchLoader)
+		if !ok {
+			buffs[i], es[i] = c.sfLoad(ctx, q)
+			continue
+		}
+
+		if _, ok = groups[l]; !ok {
+			loaders = append(loaders, l)
+		}
+		groups[l] = append(groups[l], i)
+	}
+
+	// 分批加载
+	for _, l := range loaders {
+		indexes := groups[l]
+		size := l.BatchSize()
+		if size <= 0 {
+			size = len(indexes)
+		}
+
+		for start := 0; start < len(indexes); start += size {
+			end := start + size
+			if end > len(indexes) {
+				end = len(indexes)
+			}
+
+			chunk := make([]core.IQuery, end-start)
+			for i, index := range indexes[start:end] {
+				chunk[i] = queries[index]
+			}
+
+			bs, errs := c.batchDo(ctx, chunk, func(ctx context.Context, queries []core.IQuery) ([][]byte, []error) {
+				return c.loadMany(ctx, l, queries)
+			})
+			for i, index := range indexes[start:end] {
+				buffs[index], es[index] = bs[i], errs[i]
+			}
+		}
+	}
+	return buffs, es
+}
+
+// 通过单跑模块批量执行, 单跑模块不支持批量执行时直接执行. 等待了其它调用者的加载结果时会记录到统计
+func (c *Cache) batchDo(ctx context.Context, queries []core.IQuery, fn func(ctx context.Context, queries []core.IQuery) ([][]byte, []error)) ([][]byte, []error) {
+	ctx, span := c.startSpan(ctx, core.SpanSingleFlight, nil)
+	span.SetAttribute(core.AttrBucket, queries[0].Bucket())
+	span.SetAttribute(core.AttrCount, len(queries))
+
+	// 调用者的上下文结束后单跑模块可能在后台继续加载, 所以加锁记录执行过的query并使用query的副本
+	called := make(map[uint64]bool, len(queries))
+	var calledLock sync.Mutex
+	calledFn := func(ctx context.Context, queries []core.IQuery) ([][]byte, []error) {
+		calledLock.Lock()
+		for _, q := range queries {
+			called[q.GlobalId()] = true
+		}
+		calledLock.Unlock()
+		return fn(ctx, queries)
+	}
+
+	var bs [][]byte
+	var es []error
+	if sf, ok := c.sf.(core.IContextBatchSingleFlight); ok {
+		detached := make([]core.IQuery, len(queries))
+		for i, q := range queries {
+			detached[i] = detachQuery(ctx, q)
+		}
+		bs, es = sf.DoBatchWithContext(ctx, detached, calledFn)
+	} else {
+		bs, es = calledFn(ctx, queries)
+	}
+
+	var shared int
+	calledLock.Lock()
+	for _, q := range queries {
+		if !called[q.GlobalId()] {
+			c.stats.SharedWait(q.Bucket())
+			shared++
+		}
+	}
+	calledLock.Unlock()
+	span.SetAttribute(core.AttrShared, shared)
+	endSpan(span, errs.NewErrors(es...).Err())
+	return bs, es
+}
+
+// 使用批量加载器加载数据并写入缓存, 返回数据和错误的数量和请求数量一致
+func (c *Cache) loadMany(ctx context.Context, l core.IBatchLoader, queries []core.IQuery) ([][]byte, []error) {
+	var es []error
+	var handlerErr error
+	inv := &core.Invocation{Op: core.OpLoad, Queries: append([]core.IQuery(nil), queries...)}
+	err := c.intercept(ctx, inv, func(ctx context.Context, inv *core.Invocation) error {
+		inv.Data, es = c.loadManyBytes(ctx, l, inv.Queries)
+		handlerErr = errs.NewErrors(es...).Err()
+		return handlerErr
+	})
+	syncQueries(queries, inv.Queries)
+
+	// 拦截器没有执行加载或返回了自己的错误时, 所有query都使用拦截器返回的错误
+	if (err != nil && err != handlerErr) || len(inv.Data) != len(queries) || len(es) != len(queries) {
+		if err == nil {
+			err = errors.New("interceptor returned no data")
+		}
+		buffs, es := make([][]byte, len(queries)), make([]error, len(queries))
+		for i := range es {
+			es[i] = err
+		}
+		return buffs, es
+	}
+	return inv.Data, es
+}
+
+// 使用批量加载器加载数据并写入缓存
+func (c *Cache) loadManyBytes(ctx context.Context, l core.IBatchLoader, queries []core.IQuery) ([][]byte, []error) {
+	buffs := make([][]byte, len(queries))
+	es := make([]error, len(queries))
+
+	// 加载数据
+	var results map[int]interface{}
+	loadCtx, span := c.startSpan(ctx, core.SpanLoad, nil)
+	span.SetAttribute(core.AttrBucket, queries[0].Bucket())
+	span.SetAttribute(core.AttrCount, len(queries))
+	start := time.Now()
+	err := wrap_call.WrapCall(func() (err error) {
+		results, err = loadManyWithContext(loadCtx, l, queries)
+		return err
+	})
+	latency := time.Since(start)
+	c.stats.Load(queries[0].Bucket(), latency, err)
+	endSpan(span, err)
+	if err != nil {
+		err = fmt.Errorf("load data error from loader: %s", err)
+		for i := range es {
+			es[i] = err
+		}
+		return buffs, es
+	}
+
+	items := make([]core.SetItem, 0, len(queries))
+	indexes := make([]int, 0, len(queries))
+	for i, q := range queries {
+		if e, ok := results[i].(error); ok {
+			// 数据不存在
+			if errors.Is(e, errs.NotFound) {
+				es[i] = errs.NotFound
+				data, expire := c.packNotFound(l)
+				if expire > 0 {
+					items = append(items, core.SetItem{Query: q, Data: data, Expire: expire})
+					indexes = append(indexes, i)
+				}
+				continue
+			}
+			es[i] = fmt.Errorf("load data error from loader: %s", e)
+			continue
+		}
+
+		// 编码
+		bs, err := c.marshal(ctx, q, results[i])
+		if err != nil {
+			es[i] = err
+			continue
+		}
+		buffs[i] = bs
+
+		data, expire := c.pack(q, l, bs, c.makeExpire(q, l.Expire()), latency)
+		items = append(items, core.SetItem{Query: q, Data: data, Expire: expire})
+		indexes = append(indexes, i)
+	}
+
+	// 一次性写入缓存
+	cacheErrs := c.cacheMSet(ctx, items)
+	for i, index := range indexes {
+		cacheErr := cacheErrs[i]
+		if cacheErr == nil {
+			continue
+		}
+
+		c.stats.CacheError(queries[index].Bucket(), cacheErr)
+		cacheErr = fmt.Errorf("write to cache error: %s", cacheErr)
+		if c.isDirectReturnOnCacheFault(queries[index].Bucket()) {
+			buffs[index], es[index] = nil, cacheErr
+			continue
+		}
+		c.log.Error(cacheErr)
+	}
+	return buffs, es
+}
+
+// 使用加载器加载数据, 加载器支持上下文时会传入上下文
+func loadWithContext(ctx context.Context, l core.ILoader, query core.IQuery) (interface{}, error) {
+	if cl, ok := l.(core.IContextLoader); ok {
+		return cl.LoadWithContext(ctx, query)
+	}
+	return l.Load(query)
+}
+
+// 使用批量加载器加载数据, 批量加载器支持上下文时会传入上下文
+func loadManyWithContext(ctx context.Context, l core.IBatchLoader, queries []core.IQuery) (map[int]interface{}, error) {
+	if cl, ok := l.(core.IContextBatchLoader); ok {
+		return cl.LoadManyWithContext(ctx, queries)
+	}
+	return l.LoadMany(queries)
+}
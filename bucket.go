@@ -0,0 +1,89 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package zcache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/loader"
+)
+
+// 类型化的桶, 固定了桶名, key类型和数据类型
+type Bucket[K comparable, V any] struct {
+	cache  *Cache
+	bucket string
+}
+
+// 创建一个类型化的桶, key会作为query的参数
+//
+// fn 不为nil时会通过 RegisterLoader 注册为这个桶的加载器, opts 为加载器的选项
+func NewBucket[K comparable, V any](cache *Cache, bucket string, fn func(ctx context.Context, key K) (V, error), opts ...loader.Option) *Bucket[K, V] {
+	b := &Bucket[K, V]{
+		cache:  cache,
+		bucket: bucket,
+	}
+	if fn != nil {
+		cache.RegisterLoader(bucket, loader.NewContextLoader(func(ctx context.Context, query core.IQuery) (interface{}, error) {
+			key, ok := query.Args().(K)
+			if !ok {
+				return nil, fmt.Errorf("args of query must be <%T>, got <%T>", key, query.Args())
+			}
+			return fn(ctx, key)
+		}, opts...))
+	}
+	return b
+}
+
+// 桶名
+func (b *Bucket[K, V]) Name() string {
+	return b.bucket
+}
+
+// 获取数据
+func (b *Bucket[K, V]) Get(ctx context.Context, key K) (V, error) {
+	var v V
+	err := b.cache.QueryWithContext(ctx, b.bucket, &v, NewQueryConfig().Args(key))
+	return v, err
+}
+
+// 批量获取数据, 返回数据的顺序和数量和keys一致
+//
+// 返回的错误可以通过 DecodeErrors 解包为 *Errors 获取每条数据的错误
+func (b *Bucket[K, V]) MGet(ctx context.Context, keys []K) ([]V, error) {
+	if len(keys) == 0 {
+		return []V{}, nil
+	}
+
+	qcs := make([]*QueryConfig, len(keys))
+	for i, key := range keys {
+		qcs[i] = NewQueryConfig().Args(key)
+	}
+	vs := make([]V, 0, len(keys))
+	err := b.cache.MQueryWithContext(ctx, b.bucket, &vs, qcs...)
+	return vs, err
+}
+
+// 设置数据到缓存
+//
+// ex < 0 表示永不过期, ex = 0 或未设置表示使用默认过期时间
+func (b *Bucket[K, V]) Set(ctx context.Context, key K, v V, ex ...time.Duration) error {
+	return b.cache.SetWithContext(ctx, NewQuery(b.bucket, NewQueryConfig().Args(key)), v, ex...)
+}
+
+// 删除数据
+func (b *Bucket[K, V]) Del(ctx context.Context, keys ...K) error {
+	qcs := make([]*QueryConfig, len(keys))
+	for i, key := range keys {
+		qcs[i] = NewQueryConfig().Args(key)
+	}
+	return b.cache.DelWithContext(ctx, b.bucket, qcs...)
+}
@@ -0,0 +1,164 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package zcache
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/zlyuancn/zcache/cachedb"
+	"github.com/zlyuancn/zcache/core"
+)
+
+// bucket的配置, 未设置的字段使用全局的配置
+type BucketConfig struct {
+	// 加载器, 同 RegisterLoader
+	Loader core.ILoader
+	// 存在性过滤器, 同 RegisterFilter
+	Filter core.IFilter
+	// 编解码器, 有id的编解码器会被注册到编解码器注册表
+	Codec core.ICodec
+	// 缓存数据库, 可以让不同的bucket使用不同的缓存数据库
+	CacheDB core.ICacheDB
+	// 默认过期时间, 如果 MaxExpire > Expire 且 Expire > 0, 则过期时间在 [Expire, MaxExpire-1] 区间随机. Expire < 0 表示永不过期
+	Expire, MaxExpire time.Duration
+	// 在缓存故障时是否直接返回缓存错误
+	DirectReturnOnCacheFault *bool
+}
+
+// 解析后的bucket配置
+type bucketConfig struct {
+	codec                    core.ICodec
+	cache                    core.IContextCacheDB
+	defaultExpire, maxExpire time.Duration
+	directReturnOnCacheFault *bool
+}
+
+// 注册bucket配置, 获取, 写入, 加载和删除这个bucket的数据时会使用它的配置, 重复注册会替换旧的配置
+//
+// 设置了 Loader 或 Filter 时会同时注册加载器和过滤器, 注册加载器的行为和 RegisterLoader 一致
+func (c *Cache) RegisterBucket(bucket string, conf BucketConfig) {
+	if bucket == "" {
+		panic(errors.New("bucket name is empty"))
+	}
+
+	if conf.Loader != nil {
+		c.RegisterLoader(bucket, conf.Loader)
+	}
+	if conf.Filter != nil {
+		c.RegisterFilter(bucket, conf.Filter)
+	}
+
+	bc := &bucketConfig{
+		codec:                    conf.Codec,
+		defaultExpire:            conf.Expire,
+		maxExpire:                conf.MaxExpire,
+		directReturnOnCacheFault: conf.DirectReturnOnCacheFault,
+	}
+	if bc.codec != nil {
+		c.registerCodec(bc.codec)
+	}
+	if conf.CacheDB != nil {
+		bc.cache = cachedb.ToContextCacheDB(conf.CacheDB)
+	}
+
+	c.bucketLock.Lock()
+	c.buckets[bucket] = bc
+	c.bucketLock.Unlock()
+}
+
+// 获取bucket配置, 未注册时返回nil
+func (c *Cache) getBucketConfig(bucket string) *bucketConfig {
+	c.bucketLock.RLock()
+	bc := c.buckets[bucket]
+	c.bucketLock.RUnlock()
+	return bc
+}
+
+// 获取bucket使用的编解码器
+func (c *Cache) bucketCodec(bucket string) core.ICodec {
+	if bc := c.getBucketConfig(bucket); bc != nil && bc.codec != nil {
+		return bc.codec
+	}
+	return c.codec
+}
+
+// 获取bucket使用的缓存数据库
+func (c *Cache) bucketCacheDB(bucket string) core.IContextCacheDB {
+	if bc := c.getBucketConfig(bucket); bc != nil && bc.cache != nil {
+		return bc.cache
+	}
+	return c.cache
+}
+
+// bucket在缓存故障时是否直接返回
+func (c *Cache) isDirectReturnOnCacheFault(bucket string) bool {
+	if bc := c.getBucketConfig(bucket); bc != nil && bc.directReturnOnCacheFault != nil {
+		return *bc.directReturnOnCacheFault
+	}
+	return c.directReturnOnCacheFault
+}
+
+// 获取bucket的默认过期时间
+func (c *Cache) bucketExpire(bucket string) time.Duration {
+	expire, maxExpire := c.defaultExpire, c.maxExpire
+	if bc := c.getBucketConfig(bucket); bc != nil && bc.defaultExpire != 0 {
+		expire, maxExpire = bc.defaultExpire, bc.maxExpire
+	}
+
+	if maxExpire > expire && expire > 0 {
+		return time.Duration(rand.Int63())%(maxExpire-expire) + expire
+	}
+	return expire
+}
+
+// 按使用的缓存数据库分组, 返回每个缓存数据库和使用它的bucket的索引, 分组顺序和第一次出现的顺序一致
+func (c *Cache) groupByCacheDB(n int, bucket func(i int) string) ([]core.IContextCacheDB, [][]int) {
+	c.bucketLock.RLock()
+	empty := len(c.buckets) == 0
+	c.bucketLock.RUnlock()
+	if empty {
+		indexes := make([]int, n)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return []core.IContextCacheDB{c.cache}, [][]int{indexes}
+	}
+
+	// 接口类型在 go1.18 中不满足 comparable, 所以使用缓存数据库在 dbs 中的索引分组
+	var dbs []core.IContextCacheDB
+	dbIndex := make(map[core.IContextCacheDB]int, 1)
+	_, groups := cachedb.GroupBy(n, func(i int) int {
+		db := c.bucketCacheDB(bucket(i))
+		index, ok := dbIndex[db]
+		if !ok {
+			index = len(dbs)
+			dbIndex[db] = index
+			dbs = append(dbs, db)
+		}
+		return index
+	})
+	return dbs, groups
+}
+
+// 获取所有使用的缓存数据库, 不会重复
+func (c *Cache) allCacheDB() []core.IContextCacheDB {
+	dbs := []core.IContextCacheDB{c.cache}
+	seen := map[core.IContextCacheDB]bool{c.cache: true}
+	c.bucketLock.RLock()
+	for _, bc := range c.buckets {
+		if bc.cache != nil && !seen[bc.cache] {
+			seen[bc.cache] = true
+			dbs = append(dbs, bc.cache)
+		}
+	}
+	c.bucketLock.RUnlock()
+	return dbs
+}
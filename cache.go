@@ -12,10 +12,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math/rand"
 	"sync"
 	"time"
 
+	"github.com/zlyuancn/zcache/cachedb"
 	"github.com/zlyuancn/zcache/cachedb/memory-cache"
 	"github.com/zlyuancn/zcache/loader"
 	single_sf "github.com/zlyuancn/zcache/single_flight/single-sf"
@@ -23,8 +23,11 @@ import (
 
 	"github.com/zlyuancn/zcache/codec"
 	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/envelope"
 	"github.com/zlyuancn/zcache/errs"
 	"github.com/zlyuancn/zcache/logger"
+	"github.com/zlyuancn/zcache/stats"
+	"github.com/zlyuancn/zcache/tracer"
 )
 
 const (
@@ -32,31 +35,60 @@ const (
 	defaultDirectReturnOnCacheFault = true
 	// 默认是否在注册时检测到加载器存在时panic
 	defaultPanicOnLoaderExists = true
+	// 默认数据不存在标记的过期时间
+	defaultNotFoundExpire = time.Minute
 )
 
 type Cache struct {
-	cache                    core.ICacheDB // 缓存数据库
-	defaultExpire, maxExpire time.Duration // 默认过期时间
-	directReturnOnCacheFault bool          // 在缓存故障时直接返回
+	cache                    core.IContextCacheDB // 缓存数据库
+	defaultExpire, maxExpire time.Duration        // 默认过期时间
+	directReturnOnCacheFault bool                 // 在缓存故障时直接返回
+	gracePeriod              time.Duration        // 过期数据的保留时间, 加载失败时使用
+	notFoundExpire           time.Duration        // 数据不存在标记的过期时间
+	earlyRefreshBeta         float64              // 提前刷新的系数
+	envelope                 bool                 // 总是将数据打包为信封格式
+	fallbackCodecs           []core.ICodec        // 备用编解码器, 解码没有编解码器id的数据失败时使用
 
-	codec core.ICodec // 编解码器
+	onServeStale func(query core.IQuery, loadErr error) // 返回过期数据时的回调
 
-	loaders             map[string]core.ILoader // 加载器注册表
-	panicOnLoaderExists bool                    // 注册加载器时如果加载器已存在会panic, 设为false会替换旧的加载器
-	loaderLock          sync.RWMutex            // 加载器的锁
-	sf                  core.ISingleFlight      // 单跑模块
+	codec     core.ICodec           // 编解码器
+	codecs    map[uint8]core.ICodec // 这个缓存使用的有id的编解码器, 解码带有编解码器id的数据时优先使用
+	codecLock sync.RWMutex          // 编解码器的锁
 
-	log core.ILogger // 日志
+	loaders             map[string]core.ILoader   // 加载器注册表
+	panicOnLoaderExists bool                      // 注册加载器时如果加载器已存在会panic, 设为false会替换旧的加载器
+	loaderLock          sync.RWMutex              // 加载器的锁
+	sf                  core.IContextSingleFlight // 单跑模块
+	refreshing          sync.Map                  // 正在后台刷新的数据
+
+	filters    map[string]core.IFilter // 存在性过滤器
+	filterLock sync.RWMutex            // 过滤器的锁
+
+	buckets    map[string]*bucketConfig // bucket配置
+	bucketLock sync.RWMutex             // bucket配置的锁
+
+	interceptors []core.Interceptor // 拦截器
+
+	log    core.ILogger // 日志
+	stats  core.IStats  // 统计收集器
+	tracer core.ITracer // 链路追踪
+
+	closers []func() error // 关闭缓存时调用, 用于释放缓存创建的资源
 }
 
 func NewCache(opts ...Option) *Cache {
 	c := &Cache{
 		directReturnOnCacheFault: defaultDirectReturnOnCacheFault,
+		notFoundExpire:           defaultNotFoundExpire,
 
-		codec: codec.DefaultCodec,
+		codec:  codec.DefaultCodec,
+		codecs: make(map[uint8]core.ICodec),
 
 		loaders:             make(map[string]core.ILoader),
 		panicOnLoaderExists: defaultPanicOnLoaderExists,
+
+		filters: make(map[string]core.IFilter),
+		buckets: make(map[string]*bucketConfig),
 	}
 
 	for _, o := range opts {
@@ -64,7 +96,7 @@ func NewCache(opts ...Option) *Cache {
 	}
 
 	if c.cache == nil {
-		c.cache = memory_cache.NewMemoryCache()
+		c.cache = cachedb.ToContextCacheDB(memory_cache.NewMemoryCache())
 	}
 	if c.sf == nil {
 		c.sf = single_sf.NewSingleFlight()
@@ -72,6 +104,12 @@ func NewCache(opts ...Option) *Cache {
 	if c.log == nil {
 		c.log = logger.NoLog()
 	}
+	if c.stats == nil {
+		c.stats = stats.NoStats()
+	}
+	if c.tracer == nil {
+		c.tracer = tracer.NoTracer()
+	}
 	return c
 }
 
@@ -96,6 +134,24 @@ func (c *Cache) RegisterLoaderFn(bucket string, fn loader.LoaderFn, opts ...load
 	c.RegisterLoader(bucket, l)
 }
 
+// 注册批量加载函数, 效果等同于注册批量加载器
+func (c *Cache) RegisterBatchLoaderFn(bucket string, fn loader.BatchLoaderFn, opts ...loader.Option) {
+	l := loader.NewBatchLoader(fn, opts...)
+	c.RegisterLoader(bucket, l)
+}
+
+// 注册支持上下文的加载函数, 加载函数会收到调用者的上下文
+func (c *Cache) RegisterContextLoaderFn(bucket string, fn loader.ContextLoaderFn, opts ...loader.Option) {
+	l := loader.NewContextLoader(fn, opts...)
+	c.RegisterLoader(bucket, l)
+}
+
+// 注册支持上下文的批量加载函数, 加载函数会收到调用者的上下文
+func (c *Cache) RegisterContextBatchLoaderFn(bucket string, fn loader.ContextBatchLoaderFn, opts ...loader.Option) {
+	l := loader.NewContextBatchLoader(fn, opts...)
+	c.RegisterLoader(bucket, l)
+}
+
 // 设置数据到缓存
 //
 // ex < 0 表示永不过期, ex = 0 或未设置表示使用默认过期时间
@@ -109,27 +165,86 @@ func (c *Cache) Set(query core.IQuery, a interface{}, ex ...time.Duration) error
 //
 // ex < 0 表示永不过期, ex = 0 或未设置表示使用默认过期时间
 func (c *Cache) SetWithContext(ctx context.Context, query core.IQuery, a interface{}, ex ...time.Duration) error {
-	return c.doWithContext(ctx, func() error {
-		return c.set(query, a, ex...)
+	return c.doWithContext(ctx, func(ctx context.Context) error {
+		return c.set(ctx, query, a, ex...)
 	})
 }
 
-func (c *Cache) set(query core.IQuery, a interface{}, ex ...time.Duration) error {
-	bs, err := c.marshal(a)
+func (c *Cache) set(ctx context.Context, query core.IQuery, a interface{}, ex ...time.Duration) error {
+	bs, err := c.marshal(ctx, query, a)
 	if err != nil {
 		query.SetError(err)
 		return err
 	}
 
-	err = c.cache.Set(query, bs, c.makeExpire(query, ex...))
+	inv := &core.Invocation{Op: core.OpSet, Queries: []core.IQuery{query}, Data: [][]byte{bs}}
+	err = c.intercept(ctx, inv, func(ctx context.Context, inv *core.Invocation) error {
+		return c.setBytes(ctx, inv.Queries[0], inv.Data[0], ex...)
+	})
 	if err != nil {
-		err = fmt.Errorf("write to cache error: %s", err)
 		query.SetError(err)
 		return err
 	}
 	return nil
 }
 
+// 将编码后的数据写入缓存
+func (c *Cache) setBytes(ctx context.Context, query core.IQuery, bs []byte, ex ...time.Duration) error {
+	bs, expire := c.pack(query, c.findLoader(query), bs, c.makeExpire(query, ex...), 0)
+	err := c.cacheSet(ctx, query, bs, expire)
+	if err != nil {
+		c.stats.CacheError(query.Bucket(), err)
+		return fmt.Errorf("write to cache error: %s", err)
+	}
+	c.addToFilter(ctx, query)
+	return nil
+}
+
+// 写入一条数据到缓存数据库
+func (c *Cache) cacheSet(ctx context.Context, query core.IQuery, bs []byte, expire time.Duration) error {
+	ctx, span := c.startSpan(ctx, core.SpanCacheSet, query)
+	err := c.bucketCacheDB(query.Bucket()).SetWithContext(ctx, query, bs, expire)
+	endSpan(span, err)
+	return err
+}
+
+// 批量写入数据到缓存数据库, 返回错误的数量和请求数量一致
+func (c *Cache) cacheMSet(ctx context.Context, items []core.SetItem) []error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ctx, span := c.startSpan(ctx, core.SpanCacheSet, nil)
+	span.SetAttribute(core.AttrBucket, items[0].Query.Bucket())
+	span.SetAttribute(core.AttrCount, len(items))
+	es := c.mSetByCacheDB(ctx, items)
+	endSpan(span, errs.NewErrors(es...).Err())
+	return es
+}
+
+// 按bucket使用的缓存数据库分组批量写入
+func (c *Cache) mSetByCacheDB(ctx context.Context, items []core.SetItem) []error {
+	dbs, groups := c.groupByCacheDB(len(items), func(i int) string { return items[i].Query.Bucket() })
+	if len(dbs) == 1 {
+		return dbs[0].MSetWithContext(ctx, items)
+	}
+
+	es := make([]error, len(items))
+	for g, db := range dbs {
+		groupItems := make([]core.SetItem, len(groups[g]))
+		for i, index := range groups[g] {
+			groupItems[i] = items[index]
+		}
+		groupErrs := db.MSetWithContext(ctx, groupItems)
+		for i, index := range groups[g] {
+			if i < len(groupErrs) {
+				es[index] = groupErrs[i]
+			}
+		}
+	}
+	return es
+}
+
 // 保存一条数据到缓存
 //
 // ex < 0 表示永不过期, ex = 0 或未设置表示使用默认过期时间
@@ -142,8 +257,8 @@ func (c *Cache) Save(bucket string, a interface{}, ex time.Duration, queryConfig
 // ex < 0 表示永不过期, ex = 0 或未设置表示使用默认过期时间
 func (c *Cache) SaveWithContext(ctx context.Context, bucket string, a interface{}, ex time.Duration, queryConfig ...*QueryConfig) error {
 	query := NewQuery(bucket, queryConfig...)
-	return c.doWithContext(ctx, func() error {
-		return c.set(query, a, ex)
+	return c.doWithContext(ctx, func(ctx context.Context) error {
+		return c.set(ctx, query, a, ex)
 	})
 }
 
@@ -157,8 +272,8 @@ func (c *Cache) RemoveWithContext(ctx context.Context, queries ...core.IQuery) (
 	if len(queries) == 0 {
 		return nil
 	}
-	return c.doWithContext(ctx, func() error {
-		err := c.cache.Del(queries...)
+	return c.doWithContext(ctx, func(ctx context.Context) error {
+		err := c.del(ctx, queries)
 		if err == nil {
 			return nil
 		}
@@ -183,8 +298,8 @@ func (c *Cache) DelWithContext(ctx context.Context, bucket string, queryConfigs
 	for i, qc := range queryConfigs {
 		queries[i] = NewQuery(bucket, qc)
 	}
-	return c.doWithContext(ctx, func() error {
-		err := c.cache.Del(queries...)
+	return c.doWithContext(ctx, func(ctx context.Context) error {
+		err := c.del(ctx, queries)
 		if err == nil {
 			return nil
 		}
@@ -196,6 +311,32 @@ func (c *Cache) DelWithContext(ctx context.Context, bucket string, queryConfigs
 	})
 }
 
+func (c *Cache) del(ctx context.Context, queries []core.IQuery) error {
+	inv := &core.Invocation{Op: core.OpDel, Queries: append([]core.IQuery(nil), queries...)}
+	return c.intercept(ctx, inv, func(ctx context.Context, inv *core.Invocation) error {
+		queries := inv.Queries
+		dbs, groups := c.groupByCacheDB(len(queries), func(i int) string { return queries[i].Bucket() })
+		var err error
+		for g, db := range dbs {
+			groupQueries := make([]core.IQuery, len(groups[g]))
+			for i, index := range groups[g] {
+				groupQueries[i] = queries[index]
+			}
+			e := db.DelWithContext(ctx, groupQueries...)
+			if e == nil {
+				continue
+			}
+			for _, q := range groupQueries {
+				c.stats.CacheError(q.Bucket(), e)
+			}
+			if err == nil {
+				err = e
+			}
+		}
+		return err
+	})
+}
+
 // 删除命名空间下所有数据
 func (c *Cache) DelBucket(buckets ...string) error {
 	return c.DelBucketWithContext(nil, buckets...)
@@ -206,17 +347,41 @@ func (c *Cache) DelBucketWithContext(ctx context.Context, buckets ...string) err
 	if len(buckets) == 0 {
 		return nil
 	}
-	return c.doWithContext(ctx, func() error {
-		return c.cache.DelBucket(buckets...)
+	return c.doWithContext(ctx, func(ctx context.Context) error {
+		inv := &core.Invocation{Op: core.OpDelBucket, Buckets: append([]string(nil), buckets...)}
+		return c.intercept(ctx, inv, func(ctx context.Context, inv *core.Invocation) error {
+			buckets := inv.Buckets
+			dbs, groups := c.groupByCacheDB(len(buckets), func(i int) string { return buckets[i] })
+			var err error
+			for g, db := range dbs {
+				groupBuckets := make([]string, len(groups[g]))
+				for i, index := range groups[g] {
+					groupBuckets[i] = buckets[index]
+				}
+				e := db.DelBucketWithContext(ctx, groupBuckets...)
+				if e == nil {
+					continue
+				}
+				for _, bucket := range groupBuckets {
+					c.stats.CacheError(bucket, e)
+				}
+				if err == nil {
+					err = e
+				}
+			}
+			return err
+		})
 	})
 }
 
-// 将数据解码到a
-func (c *Cache) marshal(a interface{}) ([]byte, error) {
+// 将数据编码
+func (c *Cache) marshal(ctx context.Context, query core.IQuery, a interface{}) ([]byte, error) {
 	if a == nil {
 		return nil, nil
 	}
-	bs, err := c.codec.Encode(a)
+	_, span := c.startSpan(ctx, core.SpanEncode, query)
+	bs, err := c.bucketCodec(query.Bucket()).Encode(a)
+	endSpan(span, err)
 	if err != nil {
 		return nil, fmt.Errorf("<%T> is can't encode: %s", a, err)
 	}
@@ -224,41 +389,98 @@ func (c *Cache) marshal(a interface{}) ([]byte, error) {
 }
 
 // 将数据解码到a
-func (c *Cache) unmarshal(bs []byte, a interface{}) error {
-	if len(bs) == 0 && c.codec != codec.Byte {
+//
+// 带有编解码器id的数据使用注册的编解码器解码, 其它数据使用bucket当前的编解码器解码, 失败时依次尝试备用编解码器.
+// bucket使用加密编解码器时不会尝试备用编解码器, 避免未经认证的数据被解码
+func (c *Cache) unmarshal(ctx context.Context, query core.IQuery, bs []byte, a interface{}) error {
+	dec, bs, tagged, err := c.selectDecoder(c.bucketCodec(query.Bucket()), bs)
+	if err != nil {
+		c.stats.DecodeError(query.Bucket(), err)
+		return err
+	}
+	if len(bs) == 0 && dec != codec.Byte {
 		return errs.DataIsNil
 	}
-	err := c.codec.Decode(bs, a)
+
+	_, span := c.startSpan(ctx, core.SpanDecode, query)
+	err = dec.Decode(bs, a)
+	if err != nil && !tagged && !codec.IsEncrypted(dec) {
+		for _, fallback := range c.fallbackCodecs {
+			if fallback.Decode(bs, a) == nil {
+				err = nil
+				break
+			}
+		}
+	}
+	endSpan(span, err)
 	if err != nil {
-		return fmt.Errorf("can't decode to <%T>: %s", a, err)
+		c.stats.DecodeError(query.Bucket(), err)
+		return fmt.Errorf("can't decode to <%T>: %w", a, err)
 	}
 	return nil
 }
 
-// 为一个执行添加上下文
-func (c *Cache) doWithContext(ctx context.Context, fn func() error) (err error) {
-	if ctx == nil || ctx == context.Background() || ctx == context.TODO() {
-		return wrap_call.WrapCall(fn)
+// 选择解码器, 数据没有编解码器id时使用 current, tagged 表示数据带有编解码器id
+//
+// 编解码器id优先从这个缓存使用的编解码器中查找, 然后是全局注册表.
+// current 为加密编解码器时只接受这个缓存中的加密编解码器, 防止通过伪造编解码器id绕过认证
+func (c *Cache) selectDecoder(current core.ICodec, bs []byte) (dec core.ICodec, data []byte, tagged bool, err error) {
+	e := envelope.Decode(bs)
+	if e.CodecId == 0 {
+		return current, e.Data, false, nil
+	}
+
+	if e.CodecId == codec.IdOf(current) {
+		return current, e.Data, true, nil
+	}
+	c.codecLock.RLock()
+	dec = c.codecs[e.CodecId]
+	c.codecLock.RUnlock()
+	if codec.IsEncrypted(current) {
+		if dec == nil || !codec.IsEncrypted(dec) {
+			return nil, nil, true, fmt.Errorf("codec id %d is not allowed for encrypted data", e.CodecId)
+		}
+		return dec, e.Data, true, nil
+	}
+	if dec == nil {
+		dec = codec.GetCodec(e.CodecId)
+	}
+	if dec == nil {
+		return nil, nil, true, fmt.Errorf("codec id %d is not registered", e.CodecId)
 	}
+	return dec, e.Data, true, nil
+}
 
-	done := make(chan struct{}, 1)
-	go func() {
-		err = wrap_call.WrapCall(fn)
-		done <- struct{}{}
-	}()
+// 记录这个缓存使用的编解码器, 没有id的编解码器会被忽略
+func (c *Cache) registerCodec(cc core.ICodec) {
+	id := codec.IdOf(cc)
+	if id == 0 {
+		return
+	}
+	c.codecLock.Lock()
+	c.codecs[id] = cc
+	c.codecLock.Unlock()
+}
 
-	select {
-	case <-done:
+// 为一个执行添加上下文, 上下文会传递给缓存数据库, 加载器和单跑模块
+//
+// 如果ctx已经结束会直接返回ctx的错误
+func (c *Cache) doWithContext(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
 		return err
-	case <-ctx.Done():
-		return ctx.Err()
 	}
+	return wrap_call.WrapCall(func() error {
+		return fn(ctx)
+	})
 }
 
 // 构建超时
 //
 // 有效的过期时间是非空且不为0的.
-// 优先级: ex > query的加载器函数设置的expire > 全局定义的expire
+// 优先级: ex > query的加载器函数设置的expire > bucket配置的expire > 全局定义的expire
 // 如果过期时间小于0表示永不过期.
 func (c *Cache) makeExpire(query core.IQuery, ex ...time.Duration) time.Duration {
 	if len(ex) > 0 && ex[0] != 0 {
@@ -272,13 +494,30 @@ func (c *Cache) makeExpire(query core.IQuery, ex ...time.Duration) time.Duration
 		}
 	}
 
-	if c.maxExpire > c.defaultExpire && c.defaultExpire > 0 {
-		return time.Duration(rand.Int63())%(c.maxExpire-c.defaultExpire) + (c.defaultExpire)
+	if query == nil {
+		return c.bucketExpire("")
 	}
-	return c.defaultExpire
+	return c.bucketExpire(query.Bucket())
 }
 
-// 关闭
+// 关闭, 注册的过滤器和bucket配置的缓存数据库也会被关闭. 从配置创建的缓存会关闭它创建的redis客户端
 func (c *Cache) Close() error {
-	return c.cache.Close()
+	c.filterLock.RLock()
+	for _, f := range c.filters {
+		_ = f.Close()
+	}
+	c.filterLock.RUnlock()
+
+	var err error
+	for _, db := range c.allCacheDB() {
+		if e := db.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	for _, fn := range c.closers {
+		if e := fn(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
 }
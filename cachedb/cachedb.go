@@ -0,0 +1,89 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package cachedb
+
+import (
+	"context"
+	"time"
+
+	"github.com/zlyuancn/zcache/core"
+)
+
+// 批量写入数据, 缓存数据库不支持批量写入时会逐条写入
+//
+// 返回错误的数量和请求数量一致
+func MSet(db core.ICacheDB, items []core.SetItem) []error {
+	if m, ok := db.(core.IMSetCacheDB); ok {
+		return m.MSet(items)
+	}
+
+	es := make([]error, len(items))
+	for i, item := range items {
+		es[i] = db.Set(item.Query, item.Data, item.Expire)
+	}
+	return es
+}
+
+// 按 key 分组, 返回每组的 key 和每组数据的索引, 组的顺序为 key 第一次出现的顺序, 组内索引保持原顺序
+func GroupBy[K comparable](n int, key func(i int) K) ([]K, [][]int) {
+	var keys []K
+	var groups [][]int
+	groupIndex := make(map[K]int, 1)
+	for i := 0; i < n; i++ {
+		k := key(i)
+		g, ok := groupIndex[k]
+		if !ok {
+			g = len(keys)
+			groupIndex[k] = g
+			keys, groups = append(keys, k), append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return keys, groups
+}
+
+// 将缓存数据库转为支持上下文的缓存数据库
+//
+// 如果缓存数据库本身不支持上下文, 上下文会被忽略
+func ToContextCacheDB(db core.ICacheDB) core.IContextCacheDB {
+	if c, ok := db.(core.IContextCacheDB); ok {
+		return c
+	}
+	return &contextCacheDB{ICacheDB: db}
+}
+
+var _ core.IContextCacheDB = (*contextCacheDB)(nil)
+var _ core.IMSetCacheDB = (*contextCacheDB)(nil)
+
+// 忽略上下文的缓存数据库
+type contextCacheDB struct {
+	core.ICacheDB
+}
+
+func (c *contextCacheDB) MSet(items []core.SetItem) []error {
+	return MSet(c.ICacheDB, items)
+}
+func (c *contextCacheDB) SetWithContext(_ context.Context, query core.IQuery, bs []byte, expire time.Duration) error {
+	return c.Set(query, bs, expire)
+}
+func (c *contextCacheDB) MSetWithContext(_ context.Context, items []core.SetItem) []error {
+	return MSet(c.ICacheDB, items)
+}
+func (c *contextCacheDB) GetWithContext(_ context.Context, query core.IQuery) ([]byte, error) {
+	return c.Get(query)
+}
+func (c *contextCacheDB) MGetWithContext(_ context.Context, queries ...core.IQuery) ([][]byte, []error) {
+	return c.MGet(queries...)
+}
+func (c *contextCacheDB) DelWithContext(_ context.Context, queries ...core.IQuery) error {
+	return c.Del(queries...)
+}
+func (c *contextCacheDB) DelBucketWithContext(_ context.Context, buckets ...string) error {
+	return c.DelBucket(buckets...)
+}
@@ -0,0 +1,360 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package lru_cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/errs"
+)
+
+var _ core.ICacheDB = (*LruCache)(nil)
+var _ core.IMSetCacheDB = (*LruCache)(nil)
+var _ core.ITTLCacheDB = (*LruCache)(nil)
+
+const (
+	DefaultMaxBytes        = 128 << 20       // 默认最大总字节数
+	DefaultCleanupInterval = time.Minute * 5 // 默认清除过期key时间
+)
+
+// 统计信息
+type Stats struct {
+	Count     int    // 数据条数
+	Bytes     int64  // 数据字节数
+	Evictions uint64 // 因容量限制被淘汰的数量
+	Expired   uint64 // 因过期被清除的数量
+	Rejected  uint64 // 因数据超过容量限制被拒绝写入的数量
+}
+
+type entry struct {
+	bucket   *bucket
+	key      string
+	bs       []byte
+	expireAt int64 // 过期时间戳(纳秒), 0表示永不过期
+
+	elem       *list.Element // 在全局链表中的位置
+	bucketElem *list.Element // 在bucket链表中的位置
+}
+
+func (e *entry) expired(now int64) bool {
+	return e.expireAt > 0 && now > e.expireAt
+}
+
+// 剩余过期时间, 0表示永不过期
+func (e *entry) ttl(now int64) time.Duration {
+	if e.expireAt <= 0 {
+		return 0
+	}
+	if ttl := time.Duration(e.expireAt - now); ttl > 0 {
+		return ttl
+	}
+	return 1
+}
+
+type bucket struct {
+	name     string
+	items    map[string]*entry
+	ll       *list.List // 越靠前越是最近使用
+	maxBytes int64
+	stats    Stats
+}
+
+// 有容量限制的内存缓存, 按最近最少使用(LRU)淘汰数据
+type LruCache struct {
+	buckets map[string]*bucket
+	ll      *list.List // 所有数据的使用顺序, 越靠前越是最近使用
+	stats   Stats
+	mx      sync.Mutex
+
+	maxBytes       int64
+	bucketMaxBytes map[string]int64
+
+	// 每隔一段时间后清理过期的key
+	cleanupInterval time.Duration
+	stop            chan struct{}
+	closeOnce       sync.Once
+}
+
+// 创建一个有容量限制的内存缓存
+func NewLruCache(opts ...Option) *LruCache {
+	l := &LruCache{
+		buckets:         make(map[string]*bucket),
+		ll:              list.New(),
+		maxBytes:        DefaultMaxBytes,
+		bucketMaxBytes:  make(map[string]int64),
+		cleanupInterval: DefaultCleanupInterval,
+		stop:            make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(l)
+	}
+
+	go l.janitor()
+	return l
+}
+
+// 获取桶, 调用者必须持有锁
+func (l *LruCache) bucket(name string, create bool) *bucket {
+	b, ok := l.buckets[name]
+	if ok || !create {
+		return b
+	}
+
+	b = &bucket{
+		name:     name,
+		items:    make(map[string]*entry),
+		ll:       list.New(),
+		maxBytes: l.bucketMaxBytes[name],
+	}
+	l.buckets[name] = b
+	return b
+}
+
+// 移除一条数据, 调用者必须持有锁
+func (l *LruCache) remove(e *entry) {
+	b := e.bucket
+	delete(b.items, e.key)
+	b.ll.Remove(e.bucketElem)
+	l.ll.Remove(e.elem)
+
+	size := int64(len(e.bs))
+	b.stats.Count--
+	b.stats.Bytes -= size
+	l.stats.Count--
+	l.stats.Bytes -= size
+}
+
+// 淘汰数据直到满足容量限制, 调用者必须持有锁
+func (l *LruCache) evict(b *bucket) {
+	for b.maxBytes > 0 && b.stats.Bytes > b.maxBytes {
+		e := b.ll.Back().Value.(*entry)
+		l.remove(e)
+		b.stats.Evictions++
+		l.stats.Evictions++
+	}
+	for l.stats.Bytes > l.maxBytes {
+		e := l.ll.Back().Value.(*entry)
+		l.remove(e)
+		e.bucket.stats.Evictions++
+		l.stats.Evictions++
+	}
+}
+
+func (l *LruCache) Set(query core.IQuery, bs []byte, ex time.Duration) error {
+	now := time.Now()
+	l.mx.Lock()
+	l.set(query, bs, ex, now)
+	l.mx.Unlock()
+	return nil
+}
+
+func (l *LruCache) MSet(items []core.SetItem) []error {
+	now := time.Now()
+	l.mx.Lock()
+	for _, item := range items {
+		l.set(item.Query, item.Data, item.Expire, now)
+	}
+	l.mx.Unlock()
+	return make([]error, len(items))
+}
+
+// 写入一条数据, 调用者必须持有锁
+func (l *LruCache) set(query core.IQuery, bs []byte, ex time.Duration, now time.Time) {
+	var expireAt int64
+	if ex > 0 {
+		expireAt = now.Add(ex).UnixNano()
+	}
+
+	b := l.bucket(query.Bucket(), true)
+	if e, ok := b.items[query.ArgsText()]; ok {
+		l.remove(e)
+	}
+
+	// 单条数据超过容量限制时不写入
+	size := int64(len(bs))
+	if size > l.maxBytes || (b.maxBytes > 0 && size > b.maxBytes) {
+		b.stats.Rejected++
+		l.stats.Rejected++
+		return
+	}
+
+	e := &entry{
+		bucket:   b,
+		key:      query.ArgsText(),
+		bs:       bs,
+		expireAt: expireAt,
+	}
+	e.elem = l.ll.PushFront(e)
+	e.bucketElem = b.ll.PushFront(e)
+	b.items[e.key] = e
+
+	b.stats.Count++
+	b.stats.Bytes += size
+	l.stats.Count++
+	l.stats.Bytes += size
+
+	l.evict(b)
+}
+
+// 获取一条数据, 调用者必须持有锁
+func (l *LruCache) get(query core.IQuery, now int64) (*entry, error) {
+	b := l.bucket(query.Bucket(), false)
+	if b == nil {
+		return nil, errs.CacheMiss
+	}
+	e, ok := b.items[query.ArgsText()]
+	if !ok {
+		return nil, errs.CacheMiss
+	}
+	if e.expired(now) {
+		l.remove(e)
+		b.stats.Expired++
+		l.stats.Expired++
+		return nil, errs.CacheMiss
+	}
+
+	l.ll.MoveToFront(e.elem)
+	b.ll.MoveToFront(e.bucketElem)
+	return e, nil
+}
+
+func (l *LruCache) Get(query core.IQuery) ([]byte, error) {
+	bs, _, err := l.GetWithTTL(context.Background(), query)
+	return bs, err
+}
+
+func (l *LruCache) MGet(queries ...core.IQuery) ([][]byte, []error) {
+	buffs, _, es := l.MGetWithTTL(context.Background(), queries...)
+	return buffs, es
+}
+
+func (l *LruCache) GetWithTTL(_ context.Context, query core.IQuery) ([]byte, time.Duration, error) {
+	now := time.Now().UnixNano()
+	l.mx.Lock()
+	e, err := l.get(query, now)
+	l.mx.Unlock()
+	if err != nil {
+		return nil, 0, err
+	}
+	return e.bs, e.ttl(now), nil
+}
+
+func (l *LruCache) MGetWithTTL(_ context.Context, queries ...core.IQuery) ([][]byte, []time.Duration, []error) {
+	buffs := make([][]byte, len(queries))
+	ttls := make([]time.Duration, len(queries))
+	es := make([]error, len(queries))
+
+	now := time.Now().UnixNano()
+	l.mx.Lock()
+	for i, query := range queries {
+		var e *entry
+		e, es[i] = l.get(query, now)
+		if e != nil {
+			buffs[i], ttls[i] = e.bs, e.ttl(now)
+		}
+	}
+	l.mx.Unlock()
+	return buffs, ttls, es
+}
+
+func (l *LruCache) Del(queries ...core.IQuery) error {
+	l.mx.Lock()
+	for _, query := range queries {
+		b := l.bucket(query.Bucket(), false)
+		if b == nil {
+			continue
+		}
+		if e, ok := b.items[query.ArgsText()]; ok {
+			l.remove(e)
+		}
+	}
+	l.mx.Unlock()
+	return nil
+}
+
+// 删除bucket的所有数据, bucket的淘汰, 过期和拒绝写入计数会保留
+func (l *LruCache) DelBucket(buckets ...string) error {
+	l.mx.Lock()
+	for _, name := range buckets {
+		b := l.bucket(name, false)
+		if b == nil {
+			continue
+		}
+		for _, e := range b.items {
+			l.remove(e)
+		}
+	}
+	l.mx.Unlock()
+	return nil
+}
+
+// 获取统计信息
+func (l *LruCache) Stats() Stats {
+	l.mx.Lock()
+	stats := l.stats
+	l.mx.Unlock()
+	return stats
+}
+
+// 获取bucket的统计信息
+func (l *LruCache) BucketStats(bucket string) Stats {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	if b := l.bucket(bucket, false); b != nil {
+		return b.stats
+	}
+	return Stats{}
+}
+
+// 清除过期数据
+func (l *LruCache) DeleteExpired() {
+	now := time.Now().UnixNano()
+	l.mx.Lock()
+	for _, b := range l.buckets {
+		for _, e := range b.items {
+			if e.expired(now) {
+				l.remove(e)
+				b.stats.Expired++
+				l.stats.Expired++
+			}
+		}
+	}
+	l.mx.Unlock()
+}
+
+// 定时清除过期数据
+func (l *LruCache) janitor() {
+	ticker := time.NewTicker(l.cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.DeleteExpired()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+func (l *LruCache) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.stop)
+	})
+
+	l.mx.Lock()
+	l.buckets = make(map[string]*bucket)
+	l.ll.Init()
+	l.stats.Count, l.stats.Bytes = 0, 0
+	l.mx.Unlock()
+	return nil
+}
@@ -0,0 +1,48 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package lru_cache
+
+import (
+	"time"
+)
+
+type Option func(l *LruCache)
+
+// 设置所有数据的最大总字节数, 超过时淘汰最久未使用的数据
+func WithMaxBytes(n int64) Option {
+	return func(l *LruCache) {
+		if n <= 0 {
+			n = DefaultMaxBytes
+		}
+		l.maxBytes = n
+	}
+}
+
+// 设置某个bucket的最大字节数, 超过时淘汰这个bucket中最久未使用的数据
+//
+// n <= 0 表示这个bucket只受总字节数限制
+func WithBucketMaxBytes(bucket string, n int64) Option {
+	return func(l *LruCache) {
+		if n <= 0 {
+			delete(l.bucketMaxBytes, bucket)
+			return
+		}
+		l.bucketMaxBytes[bucket] = n
+	}
+}
+
+// 设置清除过期key时间间隔
+func WithCleanupInterval(d time.Duration) Option {
+	return func(l *LruCache) {
+		if d <= 0 {
+			d = DefaultCleanupInterval
+		}
+		l.cleanupInterval = d
+	}
+}
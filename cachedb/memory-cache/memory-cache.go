@@ -9,6 +9,7 @@
 package memory_cache
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -19,6 +20,8 @@ import (
 )
 
 var _ core.ICacheDB = (*memoryCache)(nil)
+var _ core.IMSetCacheDB = (*memoryCache)(nil)
+var _ core.ITTLCacheDB = (*memoryCache)(nil)
 
 const (
 	NoExpiration           = time.Duration(-1) // 无过期时间
@@ -73,6 +76,16 @@ func (m *memoryCache) Set(query core.IQuery, bs []byte, ex time.Duration) error
 	m.bucket(query.Bucket()).Set(query.ArgsText(), bs, ex)
 	return nil
 }
+func (m *memoryCache) MSet(items []core.SetItem) []error {
+	for _, item := range items {
+		ex := item.Expire
+		if ex <= 0 {
+			ex = NoExpiration
+		}
+		m.bucket(item.Query.Bucket()).Set(item.Query.ArgsText(), item.Data, ex)
+	}
+	return make([]error, len(items))
+}
 func (m *memoryCache) Get(query core.IQuery) ([]byte, error) {
 	v, ok := m.bucket(query.Bucket()).Get(query.ArgsText())
 	if !ok {
@@ -99,6 +112,31 @@ func (m *memoryCache) MGet(queries ...core.IQuery) ([][]byte, []error) {
 	}
 	return buffs, es
 }
+func (m *memoryCache) GetWithTTL(_ context.Context, query core.IQuery) ([]byte, time.Duration, error) {
+	v, expireAt, ok := m.bucket(query.Bucket()).GetWithExpiration(query.ArgsText())
+	if !ok {
+		return nil, 0, errs.CacheMiss
+	}
+	var ttl time.Duration
+	if !expireAt.IsZero() {
+		if ttl = time.Until(expireAt); ttl <= 0 {
+			ttl = 1
+		}
+	}
+	if v == nil {
+		return nil, ttl, nil
+	}
+	return v.([]byte), ttl, nil
+}
+func (m *memoryCache) MGetWithTTL(ctx context.Context, queries ...core.IQuery) ([][]byte, []time.Duration, []error) {
+	buffs := make([][]byte, len(queries))
+	ttls := make([]time.Duration, len(queries))
+	es := make([]error, len(queries))
+	for i, query := range queries {
+		buffs[i], ttls[i], es[i] = m.GetWithTTL(ctx, query)
+	}
+	return buffs, ttls, es
+}
 
 func (m *memoryCache) Del(queries ...core.IQuery) error {
 	for _, query := range queries {
@@ -16,6 +16,7 @@ import (
 )
 
 var _ core.ICacheDB = (*noCache)(nil)
+var _ core.IMSetCacheDB = (*noCache)(nil)
 
 type noCache struct{}
 
@@ -24,6 +25,7 @@ func NoCache() core.ICacheDB { return new(noCache) }
 
 func (*noCache) Set(core.IQuery, []byte, time.Duration) error { return nil }
 func (*noCache) Get(core.IQuery) ([]byte, error)              { return nil, errs.CacheMiss }
+func (*noCache) MSet(items []core.SetItem) []error            { return make([]error, len(items)) }
 func (*noCache) MGet(queries ...core.IQuery) ([][]byte, []error) {
 	buffs := make([][]byte, len(queries))
 	es := make([]error, len(queries))
@@ -0,0 +1,184 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package redis_cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	rredis "github.com/go-redis/redis/v8"
+
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/errs"
+)
+
+// hash模式下每次写入最多清理的过期field数量
+const hashPruneCount = 100
+
+// hash模式下过期时间有序集合的key后缀
+const hashExpireKeySuffix = ":ex"
+
+// 获取一个field, 已经过期的field会被删除. KEYS: hashKey, expireKey. ARGV: field, now
+var luaHashGet = rredis.NewScript(`
+local ex = redis.call("ZSCORE", KEYS[2], ARGV[1])
+if ex and tonumber(ex) <= tonumber(ARGV[2]) then
+    redis.call("HDEL", KEYS[1], ARGV[1])
+    redis.call("ZREM", KEYS[2], ARGV[1])
+    return false
+end
+return redis.call("HGET", KEYS[1], ARGV[1])`)
+
+// 批量获取field和它的过期时间戳(毫秒), 已经过期的field会被删除. KEYS: hashKey, expireKey. ARGV: now, field...
+var luaHashMGet = rredis.NewScript(`
+local now, result = tonumber(ARGV[1]), {}
+for i = 2, #ARGV do
+    local field = ARGV[i]
+    local ex = redis.call("ZSCORE", KEYS[2], field)
+    if ex and tonumber(ex) <= now then
+        redis.call("HDEL", KEYS[1], field)
+        redis.call("ZREM", KEYS[2], field)
+        result[i * 2 - 3] = false
+        result[i * 2 - 2] = false
+    else
+        result[i * 2 - 3] = redis.call("HGET", KEYS[1], field)
+        result[i * 2 - 2] = ex or false
+    end
+end
+return result`)
+
+// 批量写入field, 然后清理一部分过期的field. KEYS: hashKey, expireKey. ARGV: now, pruneCount, [field, data, expire(毫秒)]...
+var luaHashMSet = rredis.NewScript(`
+local now = tonumber(ARGV[1])
+for i = 3, #ARGV, 3 do
+    local field, ex = ARGV[i], tonumber(ARGV[i + 2])
+    redis.call("HSET", KEYS[1], field, ARGV[i + 1])
+    if ex > 0 then
+        redis.call("ZADD", KEYS[2], now + ex, field)
+    else
+        redis.call("ZREM", KEYS[2], field)
+    end
+end
+
+-- 清理过期的field
+local expired = redis.call("ZRANGEBYSCORE", KEYS[2], "-inf", now, "LIMIT", 0, ARGV[2])
+if #expired > 0 then
+    redis.call("HDEL", KEYS[1], unpack(expired))
+    redis.call("ZREM", KEYS[2], unpack(expired))
+end
+return #expired`)
+
+// bucket是否使用hash模式
+func (r *redisCache) isHashBucket(bucket string) bool {
+	return r.hashAll || r.hashBuckets[bucket]
+}
+
+// 是否有使用hash模式的bucket
+func (r *redisCache) hasHashBucket() bool {
+	return r.hashAll || len(r.hashBuckets) > 0
+}
+
+// 构建hash模式的key, 返回保存数据的hash的key和保存过期时间的有序集合的key
+//
+// 使用 {bucket} 作为hash tag, 在集群中两个key会分配到同一个slot
+func (r *redisCache) makeHashKeys(bucket string) []string {
+	key := r.keyPrefix + "{" + bucket + "}"
+	return []string{key, key + hashExpireKeySuffix}
+}
+
+// 当前时间的毫秒时间戳
+func nowMs() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+// 将过期时间转为毫秒, <= 0 表示永不过期
+func expireMs(ex time.Duration) int64 {
+	if ex <= 0 {
+		return 0
+	}
+	if ms := ex.Milliseconds(); ms > 0 {
+		return ms
+	}
+	return 1
+}
+
+func (r *redisCache) hashGet(ctx context.Context, query core.IQuery) ([]byte, error) {
+	result, err := luaHashGet.Run(ctx, r.client, r.makeHashKeys(query.Bucket()), query.ArgsText(), nowMs()).Text()
+	if err == rredis.Nil {
+		return nil, errs.CacheMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(result), nil
+}
+
+// 批量获取一个bucket的数据, 返回的数据, 剩余过期时间和错误写入 buffs, ttls 和 es 中 indexes 对应的位置, ttls 可以为nil
+func (r *redisCache) hashMGet(ctx context.Context, bucket string, queries []core.IQuery, indexes []int, buffs [][]byte, ttls []time.Duration, es []error) {
+	now := nowMs()
+	args := make([]interface{}, 0, len(indexes)+1)
+	args = append(args, now)
+	for _, index := range indexes {
+		args = append(args, queries[index].ArgsText())
+	}
+
+	result, err := luaHashMGet.Run(ctx, r.client, r.makeHashKeys(bucket), args...).Result()
+	results, ok := result.([]interface{})
+	if err == nil && (!ok || len(results) != len(indexes)*2) {
+		err = errors.New("cached result is inconsistent with the number of requests")
+	}
+	if err != nil {
+		for _, index := range indexes {
+			es[index] = err
+		}
+		return
+	}
+
+	for i, index := range indexes {
+		switch v := results[i*2].(type) {
+		case nil:
+			es[index] = errs.CacheMiss
+			continue
+		case string:
+			buffs[index] = []byte(v)
+		default:
+			es[index] = fmt.Errorf("Unrecognized redis result type <%T>", v)
+			continue
+		}
+		if ex, ok := results[i*2+1].(string); ok && ttls != nil {
+			if exMs, err := strconv.ParseFloat(ex, 64); err == nil && int64(exMs) > now {
+				ttls[index] = time.Duration(int64(exMs)-now) * time.Millisecond
+			}
+		}
+	}
+}
+
+// 批量写入一个bucket的数据
+func (r *redisCache) hashMSet(ctx context.Context, bucket string, items []core.SetItem) error {
+	args := make([]interface{}, 0, len(items)*3+2)
+	args = append(args, nowMs(), hashPruneCount)
+	for _, item := range items {
+		args = append(args, item.Query.ArgsText(), item.Data, expireMs(item.Expire))
+	}
+	return luaHashMSet.Run(ctx, r.client, r.makeHashKeys(bucket), args...).Err()
+}
+
+// 在管道中删除一个bucket的数据
+func (r *redisCache) hashDel(ctx context.Context, pipe rredis.Pipeliner, bucket string, queries []core.IQuery) {
+	fields := make([]string, len(queries))
+	members := make([]interface{}, len(queries))
+	for i, q := range queries {
+		fields[i], members[i] = q.ArgsText(), q.ArgsText()
+	}
+	keys := r.makeHashKeys(bucket)
+	pipe.HDel(ctx, keys[0], fields...)
+	pipe.ZRem(ctx, keys[1], members...)
+}
@@ -34,3 +34,23 @@ func WithDoTimeout(timeout time.Duration) Option {
 		r.doTimeout = timeout
 	}
 }
+
+// 使用hash模式保存bucket的数据, 不传入bucket表示所有的bucket都使用hash模式
+//
+// hash模式下每个bucket保存为一个hash, 参数文本作为field, DelBucket 只需要删除一个key, 适合数据量大或者使用集群的场景.
+// 每个field的过期时间记录在一个有序集合中, 读取时检查是否过期, 写入时会清理一部分过期的field.
+// 一个bucket的所有数据都在同一个key中, 所以不适合数据量特别大的bucket
+func WithHashBuckets(buckets ...string) Option {
+	return func(r *redisCache) {
+		if len(buckets) == 0 {
+			r.hashAll = true
+			return
+		}
+		if r.hashBuckets == nil {
+			r.hashBuckets = make(map[string]bool, len(buckets))
+		}
+		for _, bucket := range buckets {
+			r.hashBuckets[bucket] = true
+		}
+	}
+}
@@ -17,6 +17,7 @@ import (
 
 	rredis "github.com/go-redis/redis/v8"
 
+	"github.com/zlyuancn/zcache/cachedb"
 	"github.com/zlyuancn/zcache/core"
 	"github.com/zlyuancn/zcache/errs"
 )
@@ -27,7 +28,9 @@ const defaultArgsSep = ":"
 // 默认操作超时时间
 const defaultDoTimeout = time.Second * 5
 
-var _ core.ICacheDB = (*redisCache)(nil)
+var _ core.IContextCacheDB = (*redisCache)(nil)
+var _ core.IMSetCacheDB = (*redisCache)(nil)
+var _ core.ITTLCacheDB = (*redisCache)(nil)
 
 type redisCache struct {
 	client    rredis.UniversalClient // redis客户端
@@ -35,6 +38,9 @@ type redisCache struct {
 	argsSep   string
 
 	doTimeout time.Duration // 操作超时时间
+
+	hashAll     bool            // 所有bucket都使用hash模式
+	hashBuckets map[string]bool // 使用hash模式的bucket
 }
 
 func NewRedisCache(redisClient rredis.UniversalClient, opts ...Option) core.ICacheDB {
@@ -53,17 +59,94 @@ func NewRedisCache(redisClient rredis.UniversalClient, opts ...Option) core.ICac
 }
 
 func (r *redisCache) Set(query core.IQuery, bs []byte, ex time.Duration) error {
+	return r.SetWithContext(context.Background(), query, bs, ex)
+}
+func (r *redisCache) SetWithContext(ctx context.Context, query core.IQuery, bs []byte, ex time.Duration) error {
 	if ex <= 0 {
 		ex = -1
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), r.doTimeout)
+	ctx, cancel := context.WithTimeout(ctx, r.doTimeout)
 	defer cancel()
+	if r.isHashBucket(query.Bucket()) {
+		return r.hashMSet(ctx, query.Bucket(), []core.SetItem{{Query: query, Data: bs, Expire: ex}})
+	}
 	return r.client.Set(ctx, r.makeKey(query), bs, ex).Err()
 }
+func (r *redisCache) MSet(items []core.SetItem) []error {
+	return r.MSetWithContext(context.Background(), items)
+}
+func (r *redisCache) MSetWithContext(ctx context.Context, items []core.SetItem) []error {
+	es := make([]error, len(items))
+	if len(items) == 0 {
+		return es
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.doTimeout)
+	defer cancel()
+	if !r.hasHashBucket() {
+		r.mSetString(ctx, items, es)
+		return es
+	}
+
+	// hash模式的bucket每个bucket一次性写入, 其它数据使用管道一次性写入
+	buckets, groups := cachedb.GroupBy(len(items), func(i int) string { return items[i].Query.Bucket() })
+	var stringItems []core.SetItem
+	var stringIndexes []int
+	for g, bucket := range buckets {
+		indexes := groups[g]
+		if !r.isHashBucket(bucket) {
+			for _, index := range indexes {
+				stringItems, stringIndexes = append(stringItems, items[index]), append(stringIndexes, index)
+			}
+			continue
+		}
+
+		bucketItems := make([]core.SetItem, len(indexes))
+		for i, index := range indexes {
+			bucketItems[i] = items[index]
+		}
+		err := r.hashMSet(ctx, bucket, bucketItems)
+		for _, index := range indexes {
+			es[index] = err
+		}
+	}
+	if len(stringItems) > 0 {
+		stringErrs := make([]error, len(stringItems))
+		r.mSetString(ctx, stringItems, stringErrs)
+		for i, index := range stringIndexes {
+			es[index] = stringErrs[i]
+		}
+	}
+	return es
+}
+
+// 使用管道一次性写入非hash模式的数据, 错误写入es中
+func (r *redisCache) mSetString(ctx context.Context, items []core.SetItem, es []error) {
+	cmds := make([]*rredis.StatusCmd, len(items))
+	_, _ = r.client.Pipelined(ctx, func(pipe rredis.Pipeliner) error {
+		for i, item := range items {
+			ex := item.Expire
+			if ex < 0 {
+				ex = 0 // 不能使用 KeepTTL
+			}
+			cmds[i] = pipe.Set(ctx, r.makeKey(item.Query), item.Data, ex)
+		}
+		return nil
+	})
+	for i, cmd := range cmds { // 每个命令都会记录自己的错误
+		es[i] = cmd.Err()
+	}
+}
 func (r *redisCache) Get(query core.IQuery) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), r.doTimeout)
+	return r.GetWithContext(context.Background(), query)
+}
+func (r *redisCache) GetWithContext(ctx context.Context, query core.IQuery) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.doTimeout)
 	defer cancel()
+	if r.isHashBucket(query.Bucket()) {
+		return r.hashGet(ctx, query)
+	}
 	result, err := r.client.Get(ctx, r.makeKey(query)).Bytes()
 	if err == rredis.Nil {
 		return nil, errs.CacheMiss
@@ -71,6 +154,65 @@ func (r *redisCache) Get(query core.IQuery) ([]byte, error) {
 	return result, err
 }
 func (r *redisCache) MGet(queries ...core.IQuery) ([][]byte, []error) {
+	return r.MGetWithContext(context.Background(), queries...)
+}
+func (r *redisCache) MGetWithContext(ctx context.Context, queries ...core.IQuery) ([][]byte, []error) {
+	buffs, _, es := r.mGet(ctx, queries, false)
+	return buffs, es
+}
+
+func (r *redisCache) GetWithTTL(ctx context.Context, query core.IQuery) ([]byte, time.Duration, error) {
+	buffs, ttls, es := r.MGetWithTTL(ctx, query)
+	return buffs[0], ttls[0], es[0]
+}
+func (r *redisCache) MGetWithTTL(ctx context.Context, queries ...core.IQuery) ([][]byte, []time.Duration, []error) {
+	return r.mGet(ctx, queries, true)
+}
+
+// 批量获取数据, withTTL 为 true 时同时获取剩余过期时间, 否则返回的 ttls 为nil
+func (r *redisCache) mGet(ctx context.Context, queries []core.IQuery, withTTL bool) ([][]byte, []time.Duration, []error) {
+	ctx, cancel := context.WithTimeout(ctx, r.doTimeout)
+	defer cancel()
+	if !r.hasHashBucket() {
+		return r.mGetString(ctx, queries, withTTL)
+	}
+
+	buffs := make([][]byte, len(queries))
+	es := make([]error, len(queries))
+	var ttls []time.Duration
+	if withTTL {
+		ttls = make([]time.Duration, len(queries))
+	}
+	buckets, groups := cachedb.GroupBy(len(queries), func(i int) string { return queries[i].Bucket() })
+	var stringQueries []core.IQuery
+	var stringIndexes []int
+	for g, bucket := range buckets {
+		if r.isHashBucket(bucket) {
+			r.hashMGet(ctx, bucket, queries, groups[g], buffs, ttls, es)
+			continue
+		}
+		for _, index := range groups[g] {
+			stringQueries, stringIndexes = append(stringQueries, queries[index]), append(stringIndexes, index)
+		}
+	}
+	if len(stringQueries) > 0 {
+		stringBuffs, stringTTLs, stringErrs := r.mGetString(ctx, stringQueries, withTTL)
+		for i, index := range stringIndexes {
+			buffs[index], es[index] = stringBuffs[i], stringErrs[i]
+			if withTTL {
+				ttls[index] = stringTTLs[i]
+			}
+		}
+	}
+	return buffs, ttls, es
+}
+
+// 批量获取非hash模式的数据, withTTL 为 true 时通过管道同时获取剩余过期时间
+func (r *redisCache) mGetString(ctx context.Context, queries []core.IQuery, withTTL bool) ([][]byte, []time.Duration, []error) {
+	if withTTL {
+		return r.mGetStringWithTTL(ctx, queries)
+	}
+
 	buffs := make([][]byte, len(queries))
 	es := make([]error, len(queries))
 
@@ -81,8 +223,6 @@ func (r *redisCache) MGet(queries ...core.IQuery) ([][]byte, []error) {
 	}
 
 	// 查询数据
-	ctx, cancel := context.WithTimeout(context.Background(), r.doTimeout)
-	defer cancel()
 	results, err := r.client.MGet(ctx, keys...).Result()
 	if err == nil && len(results) != len(queries) { // 获取到数据, 但是数量不对
 		err = errors.New("cached result is inconsistent with the number of requests")
@@ -94,7 +234,7 @@ func (r *redisCache) MGet(queries ...core.IQuery) ([][]byte, []error) {
 		for i := range es { // 一旦有错误, 所有值的错误都一样
 			es[i] = err
 		}
-		return buffs, es
+		return buffs, nil, es
 	}
 
 	// 循环检查所有结果
@@ -110,17 +250,57 @@ func (r *redisCache) MGet(queries ...core.IQuery) ([][]byte, []error) {
 			es[i] = fmt.Errorf("Unrecognized redis result type <%T>", result)
 		}
 	}
-	return buffs, es
+	return buffs, nil, es
+}
+
+// 通过管道批量获取非hash模式的数据和剩余过期时间, 永不过期的数据的剩余过期时间为0
+func (r *redisCache) mGetStringWithTTL(ctx context.Context, queries []core.IQuery) ([][]byte, []time.Duration, []error) {
+	buffs := make([][]byte, len(queries))
+	ttls := make([]time.Duration, len(queries))
+	es := make([]error, len(queries))
+
+	getCmds := make([]*rredis.StringCmd, len(queries))
+	ttlCmds := make([]*rredis.DurationCmd, len(queries))
+	pipe := r.client.Pipeline()
+	for i, query := range queries {
+		key := r.makeKey(query)
+		getCmds[i] = pipe.Get(ctx, key)
+		ttlCmds[i] = pipe.PTTL(ctx, key)
+	}
+	_, _ = pipe.Exec(ctx) // 每个命令的错误在下面检查
+
+	for i := range queries {
+		bs, err := getCmds[i].Bytes()
+		if err == rredis.Nil {
+			es[i] = errs.CacheMiss
+			continue
+		}
+		if err != nil {
+			es[i] = err
+			continue
+		}
+		buffs[i] = bs
+		if ttl, err := ttlCmds[i].Result(); err == nil && ttl > 0 { // -1 表示永不过期, -2 表示已经被删除
+			ttls[i] = ttl
+		}
+	}
+	return buffs, ttls, es
 }
 
 func (r *redisCache) Del(queries ...core.IQuery) error {
+	return r.DelWithContext(context.Background(), queries...)
+}
+func (r *redisCache) DelWithContext(ctx context.Context, queries ...core.IQuery) error {
+	ctx, cancel := context.WithTimeout(ctx, r.doTimeout)
+	defer cancel()
+	if r.hasHashBucket() {
+		return r.delMixed(ctx, queries)
+	}
+
 	keys := make([]string, len(queries))
 	for i, query := range queries {
 		keys[i] = r.makeKey(query)
 	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), r.doTimeout)
-	defer cancel()
 	err := r.client.Del(ctx, keys...).Err()
 	if err == rredis.Nil { // 虽然测试了不会出现 redis.Nil, 但是我们要考虑
 		return nil
@@ -128,11 +308,52 @@ func (r *redisCache) Del(queries ...core.IQuery) error {
 	return err
 }
 
+// 使用管道删除数据, hash模式的bucket删除hash中的field
+func (r *redisCache) delMixed(ctx context.Context, queries []core.IQuery) error {
+	buckets, groups := cachedb.GroupBy(len(queries), func(i int) string { return queries[i].Bucket() })
+	_, err := r.client.Pipelined(ctx, func(pipe rredis.Pipeliner) error {
+		var keys []string
+		for g, bucket := range buckets {
+			indexes := groups[g]
+			if !r.isHashBucket(bucket) {
+				for _, index := range indexes {
+					keys = append(keys, r.makeKey(queries[index]))
+				}
+				continue
+			}
+
+			bucketQueries := make([]core.IQuery, len(indexes))
+			for i, index := range indexes {
+				bucketQueries[i] = queries[index]
+			}
+			r.hashDel(ctx, pipe, bucket, bucketQueries)
+		}
+		if len(keys) > 0 {
+			pipe.Del(ctx, keys...)
+		}
+		return nil
+	})
+	if err == rredis.Nil {
+		return nil
+	}
+	return err
+}
+
 func (r *redisCache) DelBucket(buckets ...string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), r.doTimeout)
+	return r.DelBucketWithContext(context.Background(), buckets...)
+}
+func (r *redisCache) DelBucketWithContext(ctx context.Context, buckets ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.doTimeout)
 	defer cancel()
 
 	for _, bucket := range buckets {
+		if r.isHashBucket(bucket) { // hash模式只需要删除hash和它的过期时间
+			if err := r.client.Del(ctx, r.makeHashKeys(bucket)...).Err(); err != nil && err != rredis.Nil {
+				return err
+			}
+			continue
+		}
+
 		key := r.keyPrefix + bucket + defaultArgsSep + "*"
 		if err := r.scanDelKey(ctx, key); err != nil {
 			return err
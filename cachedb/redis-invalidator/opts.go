@@ -0,0 +1,77 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package redis_invalidator
+
+import (
+	"time"
+
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/logger"
+)
+
+type Option func(i *Invalidator)
+
+// 设置广播频道
+func WithChannel(channel string) Option {
+	return func(i *Invalidator) {
+		if channel == "" {
+			channel = DefaultChannel
+		}
+		i.channel = channel
+	}
+}
+
+// 设置发布超时时间
+func WithPublishTimeout(timeout time.Duration) Option {
+	return func(i *Invalidator) {
+		if timeout <= 0 {
+			timeout = DefaultPublishTimeout
+		}
+		i.publishTimeout = timeout
+	}
+}
+
+// 设置订阅连接的心跳间隔, 超过这个时间没有收到消息会发送ping检查连接
+func WithPingInterval(interval time.Duration) Option {
+	return func(i *Invalidator) {
+		if interval <= 0 {
+			interval = DefaultPingInterval
+		}
+		i.pingInterval = interval
+	}
+}
+
+// 设置订阅断开后的重连间隔
+func WithReconnectInterval(interval time.Duration) Option {
+	return func(i *Invalidator) {
+		if interval <= 0 {
+			interval = DefaultReconnectInterval
+		}
+		i.reconnectInterval = interval
+	}
+}
+
+// 设置重连成功后的回调
+//
+// 断线期间的失效事件会丢失, 可以在这里清理本地缓存
+func WithOnReconnect(fn func(local core.ICacheDB)) Option {
+	return func(i *Invalidator) {
+		i.onReconnect = fn
+	}
+}
+
+// 设置日志组件
+func WithLogger(log core.ILogger) Option {
+	return func(i *Invalidator) {
+		if log == nil {
+			log = logger.NoLog()
+		}
+		i.log = log
+	}
+}
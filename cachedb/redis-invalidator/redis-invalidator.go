@@ -0,0 +1,298 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package redis_invalidator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	rredis "github.com/go-redis/redis/v8"
+
+	"github.com/zlyuancn/zcache/cachedb"
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/logger"
+	"github.com/zlyuancn/zcache/query"
+)
+
+const (
+	// 默认广播频道
+	DefaultChannel = "zcache:invalidate"
+	// 默认发布超时时间
+	DefaultPublishTimeout = time.Second * 5
+	// 默认心跳间隔
+	DefaultPingInterval = time.Second * 30
+	// 默认重连间隔
+	DefaultReconnectInterval = time.Second
+)
+
+// 失效事件
+type Event struct {
+	Bucket   string `json:"bucket"`
+	ArgsText string `json:"args_text,omitempty"`
+	All      bool   `json:"all,omitempty"` // 整个bucket失效
+}
+
+// 广播消息
+type message struct {
+	Source string   `json:"source"` // 发布者id, 用于忽略自己发出的消息
+	Events []*Event `json:"events"`
+}
+
+// 基于 redis 发布订阅的本地缓存失效广播器
+//
+// 通过 Wrap 包装的缓存数据库在 Set, Del, DelBucket 成功后会广播失效事件,
+// 其它实例收到事件后会从本地缓存数据库中删除对应的数据.
+type Invalidator struct {
+	client rredis.UniversalClient
+	local  core.ICacheDB // 收到失效事件时从这里删除数据
+	id     string        // 实例id
+
+	channel           string
+	publishTimeout    time.Duration
+	pingInterval      time.Duration
+	reconnectInterval time.Duration
+	onReconnect       func(local core.ICacheDB)
+
+	log core.ILogger
+
+	sub       *rredis.PubSub
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// 创建一个失效广播器, 它会立即开始订阅失效事件
+func NewInvalidator(client rredis.UniversalClient, local core.ICacheDB, opts ...Option) *Invalidator {
+	if local == nil {
+		panic(errors.New("local cache db is nil"))
+	}
+
+	i := &Invalidator{
+		client: client,
+		local:  local,
+		id:     fmt.Sprintf("%d-%d-%d", os.Getpid(), time.Now().UnixNano(), rand.Int63()),
+
+		channel:           DefaultChannel,
+		publishTimeout:    DefaultPublishTimeout,
+		pingInterval:      DefaultPingInterval,
+		reconnectInterval: DefaultReconnectInterval,
+
+		done: make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(i)
+	}
+	if i.log == nil {
+		i.log = logger.NoLog()
+	}
+
+	i.ctx, i.cancel = context.WithCancel(context.Background())
+	i.sub = i.client.Subscribe(i.ctx, i.channel)
+	go i.subscribe()
+	return i
+}
+
+// 广播失效事件
+func (i *Invalidator) Publish(events ...*Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	bs, err := json.Marshal(&message{Source: i.id, Events: events})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), i.publishTimeout)
+	defer cancel()
+	return i.client.Publish(ctx, i.channel, bs).Err()
+}
+
+// 订阅失效事件
+func (i *Invalidator) subscribe() {
+	defer close(i.done)
+
+	var subscribed, disconnected bool
+	for {
+		msg, err := i.sub.ReceiveTimeout(i.ctx, i.pingInterval)
+		if i.ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			if e, ok := err.(interface{ Timeout() bool }); ok && e.Timeout() && !disconnected {
+				// 没有消息, 检查连接是否正常. ping失败时连接会被关闭, 下次接收消息时会重连并重新订阅
+				if err = i.sub.Ping(i.ctx); err == nil {
+					continue
+				}
+			}
+
+			if !disconnected {
+				disconnected = true
+				i.log.Error(fmt.Errorf("invalidator subscribe error, it will be reconnect. channel: %s, err: %s", i.channel, err))
+			}
+			select {
+			case <-i.ctx.Done():
+				return
+			case <-time.After(i.reconnectInterval):
+			}
+			continue
+		}
+
+		switch v := msg.(type) {
+		case *rredis.Subscription:
+			if v.Kind != "subscribe" {
+				continue
+			}
+			if subscribed && i.onReconnect != nil { // 不是第一次订阅, 说明发生了重连
+				i.onReconnect(i.local)
+			}
+			subscribed, disconnected = true, false
+		case *rredis.Message:
+			disconnected = false
+			i.handleMessage(v.Payload)
+		case *rredis.Pong:
+			disconnected = false
+		}
+	}
+}
+
+// 处理失效消息
+func (i *Invalidator) handleMessage(payload string) {
+	var msg message
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		i.log.Error(fmt.Errorf("invalidator can't decode message: %s", err))
+		return
+	}
+	if msg.Source == i.id { // 自己发出的消息, 本地缓存已经处理过了
+		return
+	}
+
+	var buckets []string
+	queries := make([]core.IQuery, 0, len(msg.Events))
+	for _, e := range msg.Events {
+		if e == nil || e.Bucket == "" {
+			continue
+		}
+		if e.All {
+			buckets = append(buckets, e.Bucket)
+			continue
+		}
+		queries = append(queries, query.NewQuery(e.Bucket, query.WithArgs(e.ArgsText)))
+	}
+
+	if len(queries) > 0 {
+		if err := i.local.Del(queries...); err != nil {
+			i.log.Error(fmt.Errorf("invalidator del local cache error: %s", err))
+		}
+	}
+	if len(buckets) > 0 {
+		if err := i.local.DelBucket(buckets...); err != nil {
+			i.log.Error(fmt.Errorf("invalidator del local bucket error: %s", err))
+		}
+	}
+}
+
+// 包装缓存数据库, 在 Set, Del, DelBucket 成功后广播失效事件
+func (i *Invalidator) Wrap(db core.ICacheDB) core.ICacheDB {
+	return &publishCache{IContextCacheDB: cachedb.ToContextCacheDB(db), inv: i}
+}
+
+// 停止订阅
+func (i *Invalidator) Close() error {
+	i.closeOnce.Do(func() {
+		i.cancel()
+		_ = i.sub.Close() // 关闭连接以打断正在阻塞的读取
+		<-i.done
+	})
+	return nil
+}
+
+var _ core.IContextCacheDB = (*publishCache)(nil)
+var _ core.IMSetCacheDB = (*publishCache)(nil)
+
+// 数据变更后广播失效事件的缓存数据库
+type publishCache struct {
+	core.IContextCacheDB
+	inv *Invalidator
+}
+
+func (p *publishCache) publish(events ...*Event) {
+	if err := p.inv.Publish(events...); err != nil {
+		p.inv.log.Error(fmt.Errorf("invalidator publish error: %s", err))
+	}
+}
+
+func (p *publishCache) Set(query core.IQuery, bs []byte, ex time.Duration) error {
+	return p.SetWithContext(context.Background(), query, bs, ex)
+}
+func (p *publishCache) SetWithContext(ctx context.Context, query core.IQuery, bs []byte, ex time.Duration) error {
+	if err := p.IContextCacheDB.SetWithContext(ctx, query, bs, ex); err != nil {
+		return err
+	}
+	p.publish(&Event{Bucket: query.Bucket(), ArgsText: query.ArgsText()})
+	return nil
+}
+
+func (p *publishCache) MSet(items []core.SetItem) []error {
+	return p.MSetWithContext(context.Background(), items)
+}
+func (p *publishCache) MSetWithContext(ctx context.Context, items []core.SetItem) []error {
+	es := p.IContextCacheDB.MSetWithContext(ctx, items)
+	events := make([]*Event, 0, len(items))
+	for i, item := range items {
+		if es[i] == nil {
+			events = append(events, &Event{Bucket: item.Query.Bucket(), ArgsText: item.Query.ArgsText()})
+		}
+	}
+	p.publish(events...)
+	return es
+}
+
+func (p *publishCache) Del(queries ...core.IQuery) error {
+	return p.DelWithContext(context.Background(), queries...)
+}
+func (p *publishCache) DelWithContext(ctx context.Context, queries ...core.IQuery) error {
+	if err := p.IContextCacheDB.DelWithContext(ctx, queries...); err != nil {
+		return err
+	}
+	events := make([]*Event, len(queries))
+	for i, q := range queries {
+		events[i] = &Event{Bucket: q.Bucket(), ArgsText: q.ArgsText()}
+	}
+	p.publish(events...)
+	return nil
+}
+
+func (p *publishCache) DelBucket(buckets ...string) error {
+	return p.DelBucketWithContext(context.Background(), buckets...)
+}
+func (p *publishCache) DelBucketWithContext(ctx context.Context, buckets ...string) error {
+	if err := p.IContextCacheDB.DelBucketWithContext(ctx, buckets...); err != nil {
+		return err
+	}
+	events := make([]*Event, len(buckets))
+	for i, bucket := range buckets {
+		events[i] = &Event{Bucket: bucket, All: true}
+	}
+	p.publish(events...)
+	return nil
+}
+
+func (p *publishCache) Close() error {
+	_ = p.inv.Close()
+	return p.IContextCacheDB.Close()
+}
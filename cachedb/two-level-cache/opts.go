@@ -0,0 +1,40 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package two_level_cache
+
+import (
+	"time"
+
+	"github.com/zlyuancn/zcache/cachedb"
+	"github.com/zlyuancn/zcache/core"
+)
+
+type Option func(t *twoLevelCache)
+
+// 设置一级缓存数据库, 默认使用 memory_cache
+func WithL1CacheDB(l1 core.ICacheDB) Option {
+	return func(t *twoLevelCache) {
+		if l1 != nil {
+			t.l1 = cachedb.ToContextCacheDB(l1)
+		}
+	}
+}
+
+// 设置一级缓存的过期时间
+//
+// 写入一级缓存时如果传入的过期时间大于0且小于这个值, 则使用传入的过期时间.
+// 如果 expire <= 0, 则使用默认的一级缓存过期时间
+func WithL1Expire(expire time.Duration) Option {
+	return func(t *twoLevelCache) {
+		if expire <= 0 {
+			expire = DefaultL1Expire
+		}
+		t.l1Expire = expire
+	}
+}
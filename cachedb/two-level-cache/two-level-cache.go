@@ -0,0 +1,219 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package two_level_cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/zlyuancn/zcache/cachedb"
+	"github.com/zlyuancn/zcache/cachedb/memory-cache"
+	"github.com/zlyuancn/zcache/core"
+)
+
+var _ core.IContextCacheDB = (*twoLevelCache)(nil)
+var _ core.IMSetCacheDB = (*twoLevelCache)(nil)
+
+// 默认一级缓存过期时间
+const DefaultL1Expire = time.Second * 30
+
+type twoLevelCache struct {
+	l1 core.IContextCacheDB // 一级缓存, 一般是本地缓存
+	l2 core.IContextCacheDB // 二级缓存, 一般是远程缓存
+	// 二级缓存支持获取剩余过期时间时不为nil
+	ttlL2 core.ITTLCacheDB
+
+	l1Expire time.Duration // 一级缓存过期时间
+}
+
+// 创建一个二级缓存, 在 l2 前面加一层本地缓存
+//
+// 读取时优先读取一级缓存, 一级缓存未命中时从二级缓存读取并写入一级缓存.
+// l2 实现了 core.ITTLCacheDB 时, 写入一级缓存的过期时间不会超过数据在二级缓存中的剩余过期时间.
+// 写入和删除会同时作用于两级缓存.
+func NewTwoLevelCache(l2 core.ICacheDB, opts ...Option) core.ICacheDB {
+	if l2 == nil {
+		panic(errors.New("l2 cache db is nil"))
+	}
+
+	t := &twoLevelCache{
+		l2:       cachedb.ToContextCacheDB(l2),
+		l1Expire: DefaultL1Expire,
+	}
+	t.ttlL2, _ = l2.(core.ITTLCacheDB)
+	for _, o := range opts {
+		o(t)
+	}
+
+	if t.l1 == nil {
+		t.l1 = cachedb.ToContextCacheDB(memory_cache.NewMemoryCache())
+	}
+	return t
+}
+
+// 构建一级缓存的过期时间
+func (t *twoLevelCache) makeL1Expire(ex time.Duration) time.Duration {
+	if ex > 0 && ex < t.l1Expire {
+		return ex
+	}
+	return t.l1Expire
+}
+
+func (t *twoLevelCache) Set(query core.IQuery, bs []byte, ex time.Duration) error {
+	return t.SetWithContext(context.Background(), query, bs, ex)
+}
+func (t *twoLevelCache) SetWithContext(ctx context.Context, query core.IQuery, bs []byte, ex time.Duration) error {
+	if err := t.l2.SetWithContext(ctx, query, bs, ex); err != nil {
+		_ = t.l1.DelWithContext(ctx, query) // 二级缓存写入失败时一级缓存中的数据已经不可信了
+		return err
+	}
+	return t.l1.SetWithContext(ctx, query, bs, t.makeL1Expire(ex))
+}
+
+func (t *twoLevelCache) MSet(items []core.SetItem) []error {
+	return t.MSetWithContext(context.Background(), items)
+}
+func (t *twoLevelCache) MSetWithContext(ctx context.Context, items []core.SetItem) []error {
+	es := t.l2.MSetWithContext(ctx, items)
+
+	l1Items := make([]core.SetItem, 0, len(items))
+	l1Indexes := make([]int, 0, len(items))
+	var failed []core.IQuery
+	for i, item := range items {
+		if es[i] != nil {
+			failed = append(failed, item.Query) // 二级缓存写入失败时一级缓存中的数据已经不可信了
+			continue
+		}
+		item.Expire = t.makeL1Expire(item.Expire)
+		l1Items = append(l1Items, item)
+		l1Indexes = append(l1Indexes, i)
+	}
+	if len(failed) > 0 {
+		_ = t.l1.DelWithContext(ctx, failed...)
+	}
+
+	l1Errs := t.l1.MSetWithContext(ctx, l1Items)
+	for i, index := range l1Indexes {
+		es[index] = l1Errs[i]
+	}
+	return es
+}
+
+func (t *twoLevelCache) Get(query core.IQuery) ([]byte, error) {
+	return t.GetWithContext(context.Background(), query)
+}
+func (t *twoLevelCache) GetWithContext(ctx context.Context, query core.IQuery) ([]byte, error) {
+	bs, err := t.l1.GetWithContext(ctx, query)
+	if err == nil {
+		return bs, nil
+	}
+
+	// 一级缓存的任何错误都视为未命中
+	var ttl time.Duration
+	if t.ttlL2 != nil {
+		bs, ttl, err = t.ttlL2.GetWithTTL(ctx, query)
+	} else {
+		bs, err = t.l2.GetWithContext(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 提升到一级缓存
+	_ = t.l1.SetWithContext(ctx, query, bs, t.makeL1Expire(ttl))
+	return bs, nil
+}
+
+func (t *twoLevelCache) MGet(queries ...core.IQuery) ([][]byte, []error) {
+	return t.MGetWithContext(context.Background(), queries...)
+}
+func (t *twoLevelCache) MGetWithContext(ctx context.Context, queries ...core.IQuery) ([][]byte, []error) {
+	buffs, es := t.l1.MGetWithContext(ctx, queries...)
+
+	// 收集一级缓存未命中的请求
+	missIndexes := make([]int, 0, len(queries))
+	for i, err := range es {
+		if err != nil {
+			missIndexes = append(missIndexes, i)
+		}
+	}
+	if len(missIndexes) == 0 {
+		return buffs, es
+	}
+
+	missQueries := make([]core.IQuery, len(missIndexes))
+	for i, index := range missIndexes {
+		missQueries[i] = queries[index]
+	}
+
+	// 一次性从二级缓存获取
+	var l2Buffs [][]byte
+	var l2TTLs []time.Duration
+	var l2Errs []error
+	if t.ttlL2 != nil {
+		l2Buffs, l2TTLs, l2Errs = t.ttlL2.MGetWithTTL(ctx, missQueries...)
+	} else {
+		l2Buffs, l2Errs = t.l2.MGetWithContext(ctx, missQueries...)
+		l2TTLs = make([]time.Duration, len(missQueries))
+	}
+	if len(l2Buffs) != len(missQueries) || len(l2Errs) != len(missQueries) || len(l2TTLs) != len(missQueries) {
+		err := errors.New("cached result is inconsistent with the number of requests")
+		for _, index := range missIndexes {
+			es[index] = err
+		}
+		return buffs, es
+	}
+
+	promotes := make([]core.SetItem, 0, len(missQueries))
+	for i, index := range missIndexes {
+		buffs[index], es[index] = l2Buffs[i], l2Errs[i]
+		if l2Errs[i] == nil {
+			promotes = append(promotes, core.SetItem{Query: missQueries[i], Data: l2Buffs[i], Expire: t.makeL1Expire(l2TTLs[i])})
+		}
+	}
+
+	// 提升到一级缓存
+	if len(promotes) > 0 {
+		_ = t.l1.MSetWithContext(ctx, promotes)
+	}
+	return buffs, es
+}
+
+func (t *twoLevelCache) Del(queries ...core.IQuery) error {
+	return t.DelWithContext(context.Background(), queries...)
+}
+func (t *twoLevelCache) DelWithContext(ctx context.Context, queries ...core.IQuery) error {
+	// 先删除二级缓存, 减少一级缓存被旧数据回填的可能
+	err := t.l2.DelWithContext(ctx, queries...)
+	return firstErr(err, t.l1.DelWithContext(ctx, queries...))
+}
+
+func (t *twoLevelCache) DelBucket(buckets ...string) error {
+	return t.DelBucketWithContext(context.Background(), buckets...)
+}
+func (t *twoLevelCache) DelBucketWithContext(ctx context.Context, buckets ...string) error {
+	err := t.l2.DelBucketWithContext(ctx, buckets...)
+	return firstErr(err, t.l1.DelBucketWithContext(ctx, buckets...))
+}
+
+func (t *twoLevelCache) Close() error {
+	err := t.l2.Close()
+	return firstErr(err, t.l1.Close())
+}
+
+// 返回第一个不为nil的错误
+func firstErr(es ...error) error {
+	for _, err := range es {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -10,7 +10,9 @@ package codec
 
 import (
 	"bytes"
+	"encoding/gob"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 
 	"github.com/golang/protobuf/proto"
@@ -30,6 +32,29 @@ var (
 	MsgPack = new(msgPackCodec)
 	// ProtoBuffer
 	ProtoBuffer = new(protoBufferCodec)
+	// 使用go内置的gob包进行编解码, 接口类型的值需要先通过 RegisterGobType 注册具体类型
+	Gob = new(gobCodec)
+	// 使用go内置的xml包进行编解码
+	Xml = new(xmlCodec)
+	// 使用 google.golang.org/protobuf 进行编解码, 同时支持新旧两种api生成的消息
+	ProtoBufferV2 = NewProtoBufferV2Codec()
+)
+
+// 内置编解码器的id, 自定义编解码器的id应该从 MinCustomId 开始
+//
+// [1, CompressIdOffset) 为内置编解码器, [CompressIdOffset, EncryptIdOffset) 为压缩编解码器,
+// [EncryptIdOffset, MinCustomId) 为加密编解码器
+const (
+	ByteId          uint8 = 1
+	JsonId          uint8 = 2
+	JsonIteratorId  uint8 = 3
+	MsgPackId       uint8 = 4
+	ProtoBufferId   uint8 = 5
+	GobId           uint8 = 6
+	XmlId           uint8 = 7
+	ProtoBufferV2Id uint8 = 8
+
+	MinCustomId uint8 = 128
 )
 
 // 默认的编解码器
@@ -38,6 +63,8 @@ var DefaultCodec = MsgPack
 // 不进行编解码
 type byteCodec struct{}
 
+func (*byteCodec) CodecId() uint8 { return ByteId }
+
 func (*byteCodec) Encode(a interface{}) ([]byte, error) {
 	switch data := a.(type) {
 	case []byte:
@@ -67,6 +94,8 @@ func (*byteCodec) Decode(data []byte, a interface{}) error {
 // 使用go内置的json包进行编解码
 type jsonCodec struct{}
 
+func (*jsonCodec) CodecId() uint8 { return JsonId }
+
 func (*jsonCodec) Encode(a interface{}) ([]byte, error) {
 	return json.Marshal(a)
 }
@@ -78,6 +107,8 @@ func (*jsonCodec) Decode(data []byte, a interface{}) error {
 // 使用第三方包json-iterator进行编解码
 type jsonIteratorCodec struct{}
 
+func (*jsonIteratorCodec) CodecId() uint8 { return JsonIteratorId }
+
 func (*jsonIteratorCodec) Encode(a interface{}) ([]byte, error) {
 	return jsoniter.Marshal(a)
 }
@@ -89,6 +120,8 @@ func (*jsonIteratorCodec) Decode(data []byte, a interface{}) error {
 // MsgPack编解码器
 type msgPackCodec struct{}
 
+func (*msgPackCodec) CodecId() uint8 { return MsgPackId }
+
 func (*msgPackCodec) Encode(a interface{}) ([]byte, error) {
 	var buf bytes.Buffer
 	enc := msgpack.NewEncoder(&buf)
@@ -107,6 +140,8 @@ func (*msgPackCodec) Decode(data []byte, a interface{}) error {
 // ProtoBuffer编解码器
 type protoBufferCodec struct{}
 
+func (*protoBufferCodec) CodecId() uint8 { return ProtoBufferId }
+
 func (*protoBufferCodec) Encode(a interface{}) ([]byte, error) {
 	if m, ok := a.(proto.Message); ok {
 		return proto.Marshal(m)
@@ -122,3 +157,40 @@ func (*protoBufferCodec) Decode(data []byte, a interface{}) error {
 
 	return fmt.Errorf("<%T> can't convert to proto.Message", a)
 }
+
+// 使用go内置的gob包进行编解码
+type gobCodec struct{}
+
+func (*gobCodec) CodecId() uint8 { return GobId }
+
+func (*gobCodec) Encode(a interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(a)
+	return buf.Bytes(), err
+}
+
+func (*gobCodec) Decode(data []byte, a interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(a)
+}
+
+// 注册gob编解码时接口类型的值可能使用的具体类型, 同 gob.Register
+//
+// 编码和解码的进程都需要注册, 否则无法编解码接口类型的值
+func RegisterGobType(values ...interface{}) {
+	for _, v := range values {
+		gob.Register(v)
+	}
+}
+
+// 使用go内置的xml包进行编解码
+type xmlCodec struct{}
+
+func (*xmlCodec) CodecId() uint8 { return XmlId }
+
+func (*xmlCodec) Encode(a interface{}) ([]byte, error) {
+	return xml.Marshal(a)
+}
+
+func (*xmlCodec) Decode(data []byte, a interface{}) error {
+	return xml.Unmarshal(data, a)
+}
@@ -0,0 +1,208 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package codec
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/zlyuancn/zcache/core"
+)
+
+// 压缩算法, 也是压缩数据的标记字节
+type CompressAlgorithm byte
+
+const (
+	noCompress CompressAlgorithm = 0 // 未压缩
+
+	Gzip  CompressAlgorithm = 1
+	Flate CompressAlgorithm = 2
+	Zlib  CompressAlgorithm = 3
+)
+
+const (
+	// 默认压缩阈值, 编码后的数据达到这个大小才会压缩
+	DefaultCompressThreshold = 1024
+	// 压缩编解码器的id偏移, 压缩编解码器的id为 CompressIdOffset + 内部编解码器的id
+	CompressIdOffset uint8 = 32
+)
+
+func init() {
+	// 压缩数据的标记字节记录了压缩算法, 解码时和压缩参数无关, 所以预先注册内置编解码器的压缩版本
+	for _, c := range []core.ICodec{Byte, Json, JsonIterator, MsgPack, ProtoBuffer, Gob, Xml, ProtoBufferV2} {
+		Register(NewCompressCodec(c))
+	}
+}
+
+var _ core.ICodec = (*compressCodec)(nil)
+var _ core.ICodecId = (*compressCodec)(nil)
+
+// 压缩编解码器
+type compressCodec struct {
+	inner     core.ICodec
+	algorithm CompressAlgorithm
+	level     int
+	threshold int
+
+	writers sync.Pool
+}
+
+// 创建一个压缩编解码器, 内部编解码器编码后的数据达到阈值时会被压缩, 解码时会自动解压
+//
+// 编码后的数据第一个字节为压缩算法的标记, 0表示未压缩. 默认使用 Gzip 算法和默认压缩等级
+func NewCompressCodec(inner core.ICodec, opts ...CompressOption) core.ICodec {
+	c := &compressCodec{
+		inner:     inner,
+		algorithm: Gzip,
+		level:     flate.DefaultCompression,
+		threshold: DefaultCompressThreshold,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	c.writers.New = func() interface{} {
+		w, err := c.newWriter(io.Discard)
+		if err != nil {
+			return err
+		}
+		return w
+	}
+	return c
+}
+
+// 内部编解码器的id不在 [1, CompressIdOffset) 区间时返回0, 表示没有id
+func (c *compressCodec) CodecId() uint8 {
+	id := IdOf(c.inner)
+	if id == 0 || id >= CompressIdOffset {
+		return 0
+	}
+	return CompressIdOffset + id
+}
+
+type resetWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+func (c *compressCodec) newWriter(w io.Writer) (resetWriter, error) {
+	switch c.algorithm {
+	case Gzip:
+		return gzip.NewWriterLevel(w, c.level)
+	case Flate:
+		return flate.NewWriter(w, c.level)
+	case Zlib:
+		return zlib.NewWriterLevel(w, c.level)
+	}
+	return nil, fmt.Errorf("unknown compress algorithm %d", c.algorithm)
+}
+
+func newReader(algorithm CompressAlgorithm, r io.Reader) (io.ReadCloser, error) {
+	switch algorithm {
+	case Gzip:
+		return gzip.NewReader(r)
+	case Flate:
+		return flate.NewReader(r), nil
+	case Zlib:
+		return zlib.NewReader(r)
+	}
+	return nil, fmt.Errorf("unknown compress algorithm %d", algorithm)
+}
+
+func (c *compressCodec) Encode(a interface{}) ([]byte, error) {
+	bs, err := c.inner.Encode(a)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bs) >= c.threshold {
+		compressed, err := c.compress(bs)
+		if err != nil {
+			return nil, err
+		}
+		if len(compressed) < len(bs)+1 { // 压缩后变大的数据不压缩
+			return compressed, nil
+		}
+	}
+
+	buff := make([]byte, len(bs)+1)
+	buff[0] = byte(noCompress)
+	copy(buff[1:], bs)
+	return buff, nil
+}
+
+func (c *compressCodec) compress(bs []byte) ([]byte, error) {
+	v := c.writers.Get()
+	if err, ok := v.(error); ok {
+		return nil, err
+	}
+	w := v.(resetWriter)
+	defer c.writers.Put(w)
+
+	var buff bytes.Buffer
+	buff.Grow(len(bs)/2 + 1)
+	buff.WriteByte(byte(c.algorithm))
+	w.Reset(&buff)
+	if _, err := w.Write(bs); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+func (c *compressCodec) Decode(data []byte, a interface{}) error {
+	if len(data) == 0 {
+		return c.inner.Decode(data, a)
+	}
+
+	algorithm, data := CompressAlgorithm(data[0]), data[1:]
+	if algorithm == noCompress {
+		return c.inner.Decode(data, a)
+	}
+
+	r, err := newReader(algorithm, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("decompress error: %s", err)
+	}
+	return c.inner.Decode(bs, a)
+}
+
+type CompressOption func(c *compressCodec)
+
+// 设置压缩算法
+func WithCompressAlgorithm(algorithm CompressAlgorithm) CompressOption {
+	return func(c *compressCodec) {
+		c.algorithm = algorithm
+	}
+}
+
+// 设置压缩等级, 参考 compress/flate 的压缩等级
+func WithCompressLevel(level int) CompressOption {
+	return func(c *compressCodec) {
+		c.level = level
+	}
+}
+
+// 设置压缩阈值, 编码后的数据达到这个大小才会压缩, threshold <= 0 表示总是压缩
+func WithCompressThreshold(threshold int) CompressOption {
+	return func(c *compressCodec) {
+		c.threshold = threshold
+	}
+}
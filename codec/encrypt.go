@@ -0,0 +1,142 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package codec
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/zlyuancn/zcache/core"
+)
+
+// 加密编解码器的id偏移, 加密编解码器的id为 EncryptIdOffset + 内部编解码器的id
+const EncryptIdOffset uint8 = 64
+
+// 加密数据的格式版本
+const encryptVersion byte = 1
+
+// 加密数据的头部长度: 版本(1字节) + 密钥id(4字节)
+const encryptHeaderSize = 1 + 4
+
+var (
+	// 加密数据被篡改或损坏
+	ErrTampered = errors.New("encrypted data is tampered or corrupted")
+	// 没有找到加密数据使用的密钥
+	ErrUnknownKey = errors.New("encrypt key not found")
+)
+
+// 加密密钥
+type EncryptKey struct {
+	Id  uint32 // 密钥id, 会写入加密数据中, 解密时根据它选择密钥
+	Key []byte // AES密钥, 长度必须为16, 24或32字节
+}
+
+var _ core.ICodec = (*encryptCodec)(nil)
+var _ core.ICodecId = (*encryptCodec)(nil)
+
+// 加密编解码器
+type encryptCodec struct {
+	inner   core.ICodec
+	current uint32                 // 加密使用的密钥id
+	aeads   map[uint32]cipher.AEAD // 所有可以用于解密的密钥
+}
+
+// 创建一个加密编解码器, 使用 AES-GCM 加密内部编解码器编码后的数据, 解码时会校验数据是否被篡改
+//
+// current 用于加密, current 和 old 都可以用于解密. 轮换密钥时将新密钥设为 current, 旧密钥放到 old 中,
+// 在旧数据全部过期前保留旧密钥. 编码后的数据格式为: 版本(1字节) + 密钥id(4字节) + nonce + 密文.
+// 密钥长度错误或密钥id重复会panic
+func NewEncryptCodec(inner core.ICodec, current EncryptKey, old ...EncryptKey) core.ICodec {
+	c := &encryptCodec{
+		inner:   inner,
+		current: current.Id,
+		aeads:   make(map[uint32]cipher.AEAD, len(old)+1),
+	}
+	for _, key := range append([]EncryptKey{current}, old...) {
+		if _, ok := c.aeads[key.Id]; ok {
+			panic(fmt.Errorf("encrypt key id %d is repeated", key.Id))
+		}
+		block, err := aes.NewCipher(key.Key)
+		if err != nil {
+			panic(fmt.Errorf("encrypt key %d is invalid: %s", key.Id, err))
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			panic(fmt.Errorf("encrypt key %d is invalid: %s", key.Id, err))
+		}
+		c.aeads[key.Id] = aead
+	}
+	return c
+}
+
+// 内部编解码器的id不在 [1, EncryptIdOffset) 区间时返回0, 表示没有id
+func (c *encryptCodec) CodecId() uint8 {
+	id := IdOf(c.inner)
+	if id == 0 || id >= EncryptIdOffset {
+		return 0
+	}
+	return EncryptIdOffset + id
+}
+
+// 编解码器是否为加密编解码器, 内部编解码器为加密编解码器的压缩编解码器也算
+func IsEncrypted(c core.ICodec) bool {
+	switch v := c.(type) {
+	case *encryptCodec:
+		return true
+	case *compressCodec:
+		return IsEncrypted(v.inner)
+	}
+	return false
+}
+
+func (c *encryptCodec) Encode(a interface{}) ([]byte, error) {
+	bs, err := c.inner.Encode(a)
+	if err != nil {
+		return nil, err
+	}
+
+	aead := c.aeads[c.current]
+	buff := make([]byte, encryptHeaderSize+aead.NonceSize(), encryptHeaderSize+aead.NonceSize()+len(bs)+aead.Overhead())
+	buff[0] = encryptVersion
+	binary.BigEndian.PutUint32(buff[1:encryptHeaderSize], c.current)
+	nonce := buff[encryptHeaderSize:]
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce error: %s", err)
+	}
+
+	// 头部作为附加数据参与校验, 修改版本或密钥id也会被发现
+	return aead.Seal(buff, nonce, bs, buff[:encryptHeaderSize]), nil
+}
+
+func (c *encryptCodec) Decode(data []byte, a interface{}) error {
+	if len(data) < encryptHeaderSize || data[0] != encryptVersion {
+		return ErrTampered
+	}
+
+	keyId := binary.BigEndian.Uint32(data[1:encryptHeaderSize])
+	aead, ok := c.aeads[keyId]
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownKey, keyId)
+	}
+	if len(data) < encryptHeaderSize+aead.NonceSize()+aead.Overhead() {
+		return ErrTampered
+	}
+
+	nonce := data[encryptHeaderSize : encryptHeaderSize+aead.NonceSize()]
+	bs, err := aead.Open(nil, nonce, data[encryptHeaderSize+aead.NonceSize():], data[:encryptHeaderSize])
+	if err != nil {
+		return ErrTampered
+	}
+	return c.inner.Decode(bs, a)
+}
@@ -0,0 +1,83 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package codec
+
+import (
+	"fmt"
+
+	protoV1 "github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/zlyuancn/zcache/core"
+)
+
+var _ core.ICodec = (*protoBufferV2Codec)(nil)
+var _ core.ICodecId = (*protoBufferV2Codec)(nil)
+
+// 使用 google.golang.org/protobuf 的ProtoBuffer编解码器
+type protoBufferV2Codec struct {
+	marshal   proto.MarshalOptions
+	unmarshal proto.UnmarshalOptions
+}
+
+// 创建一个使用 google.golang.org/protobuf 的ProtoBuffer编解码器
+//
+// 默认不使用确定性编码, 解码时保留未知字段
+func NewProtoBufferV2Codec(opts ...ProtoBufferV2Option) core.ICodec {
+	c := &protoBufferV2Codec{}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+func (*protoBufferV2Codec) CodecId() uint8 { return ProtoBufferV2Id }
+
+// 转为新api的消息, 旧api生成的消息会被包装
+func toMessageV2(a interface{}) (proto.Message, error) {
+	switch m := a.(type) {
+	case proto.Message:
+		return m, nil
+	case protoV1.Message:
+		return protoV1.MessageV2(m), nil
+	}
+	return nil, fmt.Errorf("<%T> can't convert to proto.Message", a)
+}
+
+func (c *protoBufferV2Codec) Encode(a interface{}) ([]byte, error) {
+	m, err := toMessageV2(a)
+	if err != nil {
+		return nil, err
+	}
+	return c.marshal.Marshal(m)
+}
+
+func (c *protoBufferV2Codec) Decode(data []byte, a interface{}) error {
+	m, err := toMessageV2(a)
+	if err != nil {
+		return err
+	}
+	return c.unmarshal.Unmarshal(data, m)
+}
+
+type ProtoBufferV2Option func(c *protoBufferV2Codec)
+
+// 使用确定性编码, 相同的消息总是编码为相同的数据, map字段会按key排序
+func WithProtoDeterministic(deterministic ...bool) ProtoBufferV2Option {
+	return func(c *protoBufferV2Codec) {
+		c.marshal.Deterministic = len(deterministic) == 0 || deterministic[0]
+	}
+}
+
+// 解码时丢弃未知字段, 默认会保留未知字段, 重新编码时未知字段会被原样写回
+func WithProtoDiscardUnknown(discard ...bool) ProtoBufferV2Option {
+	return func(c *protoBufferV2Codec) {
+		c.unmarshal.DiscardUnknown = len(discard) == 0 || discard[0]
+	}
+}
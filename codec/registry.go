@@ -0,0 +1,96 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/zlyuancn/zcache/core"
+)
+
+var (
+	registry   = make(map[uint8]core.ICodec)
+	registryMx sync.RWMutex
+)
+
+// 内置编解码器的名称, 名称已经去掉了分隔符并转为小写
+var builtinNames = map[string]core.ICodec{
+	"byte":          Byte,
+	"json":          Json,
+	"jsoniterator":  JsonIterator,
+	"jsoniter":      JsonIterator,
+	"msgpack":       MsgPack,
+	"protobuffer":   ProtoBuffer,
+	"protobuf":      ProtoBuffer,
+	"protobufferv2": ProtoBufferV2,
+	"protobufv2":    ProtoBufferV2,
+	"gob":           Gob,
+	"xml":           Xml,
+}
+
+func init() {
+	Register(Byte)
+	Register(Json)
+	Register(JsonIterator)
+	Register(MsgPack)
+	Register(ProtoBuffer)
+	Register(Gob)
+	Register(Xml)
+	Register(ProtoBufferV2)
+}
+
+// 注册编解码器, 读取缓存数据时会根据数据中记录的编解码器id选择解码器
+//
+// 编解码器必须实现 core.ICodecId, 如果id为0或者id已经被其它类型的编解码器使用会panic.
+// 相同类型的编解码器使用同一个id时, 后注册的会替换先注册的
+func Register(codec core.ICodec) {
+	ci, ok := codec.(core.ICodecId)
+	if !ok {
+		panic(fmt.Errorf("codec <%T> not implement core.ICodecId", codec))
+	}
+	id := ci.CodecId()
+	if id == 0 {
+		panic(errors.New("codec id can't be 0"))
+	}
+
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	if old, ok := registry[id]; ok && reflect.TypeOf(old) != reflect.TypeOf(codec) {
+		panic(fmt.Errorf("codec id %d is already registered by <%T>", id, old))
+	}
+	registry[id] = codec
+}
+
+// 根据id获取编解码器, 不存在时返回nil
+func GetCodec(id uint8) core.ICodec {
+	registryMx.RLock()
+	codec := registry[id]
+	registryMx.RUnlock()
+	return codec
+}
+
+// 获取编解码器的id, 未实现 core.ICodecId 时返回0
+func IdOf(codec core.ICodec) uint8 {
+	if ci, ok := codec.(core.ICodecId); ok {
+		return ci.CodecId()
+	}
+	return 0
+}
+
+// 根据名称获取内置的编解码器, 不存在时返回nil
+//
+// 名称不区分大小写, 并且忽略 '_' 和 '-', 比如 json_iterator, JsonIterator, proto-buffer-v2 都是有效的名称
+func GetCodecByName(name string) core.ICodec {
+	name = strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+	return builtinNames[name]
+}
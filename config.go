@@ -0,0 +1,185 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package zcache
+
+import (
+	"fmt"
+	"time"
+
+	rredis "github.com/go-redis/redis/v8"
+
+	memory_cache "github.com/zlyuancn/zcache/cachedb/memory-cache"
+	redis_cache "github.com/zlyuancn/zcache/cachedb/redis-cache"
+	"github.com/zlyuancn/zcache/codec"
+	"github.com/zlyuancn/zcache/config"
+	"github.com/zlyuancn/zcache/core"
+	no_sf "github.com/zlyuancn/zcache/single_flight/no-sf"
+	redis_sf "github.com/zlyuancn/zcache/single_flight/redis-sf"
+	single_sf "github.com/zlyuancn/zcache/single_flight/single-sf"
+)
+
+// 根据配置创建缓存
+//
+// 配置无效时返回 *Errors, 包含配置中所有的错误, 可以通过 DecodeErrors 解包, 或者使用 %v 输出所有错误.
+// opts 在配置之后生效, 可以设置配置中没有的选项, 比如日志, 统计和拦截器
+func NewCacheFromConfig(conf *config.Config, opts ...Option) (*Cache, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	clients := newRedisClients()
+	var cacheDB core.ICacheDB
+	var redisClient rredis.UniversalClient // 缓存数据库的redis客户端, 单跑模块没有设置地址时使用
+	if conf.CacheDB.Type == config.CacheDBRedis {
+		cacheDB, redisClient = newRedisCacheFromConfig(conf.CacheDB.Redis, clients)
+	} else {
+		cacheDB = newCacheDBFromConfig(&conf.CacheDB, clients)
+	}
+
+	options := []Option{
+		WithCacheDB(cacheDB),
+		WithSingleFlight(newSingleFlightFromConfig(&conf.SingleFlight, redisClient, clients)),
+		WithDefaultExpire(makeExpireFromConfig(conf.DefaultExpire, conf.ExpireJitter)),
+	}
+	if conf.Codec != "" {
+		options = append(options, WithCodec(codec.GetCodecByName(conf.Codec)))
+	}
+	if conf.DirectReturnOnCacheFault != nil {
+		options = append(options, WithDirectReturnOnCacheFault(*conf.DirectReturnOnCacheFault))
+	}
+	for name, b := range conf.Buckets {
+		bc := BucketConfig{
+			Codec:                    codec.GetCodecByName(b.Codec),
+			DirectReturnOnCacheFault: b.DirectReturnOnCacheFault,
+		}
+		bc.Expire, bc.MaxExpire = makeExpireFromConfig(b.Expire, b.ExpireJitter)
+		if b.CacheDB != nil {
+			bc.CacheDB = newCacheDBFromConfig(b.CacheDB, clients)
+		}
+		options = append(options, WithBucket(name, bc))
+	}
+	options = append(options, withCloser(clients.Close))
+	return NewCache(append(options, opts...)...), nil
+}
+
+// 根据配置创建缓存数据库
+func newCacheDBFromConfig(conf *config.CacheDB, clients *redisClients) core.ICacheDB {
+	if conf.Type == config.CacheDBRedis {
+		db, _ := newRedisCacheFromConfig(conf.Redis, clients)
+		return db
+	}
+
+	var opts []memory_cache.Option
+	if conf.Memory.CleanupInterval > 0 {
+		opts = append(opts, memory_cache.WithCleanupInterval(conf.Memory.CleanupInterval.Duration()))
+	}
+	return memory_cache.NewMemoryCache(opts...)
+}
+
+// 根据配置创建redis缓存数据库, 同时返回它使用的redis客户端
+func newRedisCacheFromConfig(conf *config.Redis, clients *redisClients) (core.ICacheDB, rredis.UniversalClient) {
+	client := clients.Get(&conf.RedisClient)
+	opts := []redis_cache.Option{redis_cache.WithKeyPrefix(conf.KeyPrefix)}
+	if conf.ArgsSep != "" {
+		opts = append(opts, redis_cache.WithArgsSep(conf.ArgsSep))
+	}
+	if conf.DoTimeout > 0 {
+		opts = append(opts, redis_cache.WithDoTimeout(conf.DoTimeout.Duration()))
+	}
+	if len(conf.HashBuckets) > 0 {
+		opts = append(opts, redis_cache.WithHashBuckets(conf.HashBuckets...))
+	}
+	return redis_cache.NewRedisCache(client, opts...), client
+}
+
+// 根据配置创建的redis客户端, 连接选项相同的配置共用一个客户端
+//
+// 获取的客户端不会被使用者关闭, 所有客户端在 Close 时统一关闭
+type redisClients struct {
+	clients map[string]rredis.UniversalClient // 原始客户端, key为连接选项
+	shared  map[string]rredis.UniversalClient // 提供给使用者的客户端
+}
+
+func newRedisClients() *redisClients {
+	return &redisClients{
+		clients: make(map[string]rredis.UniversalClient),
+		shared:  make(map[string]rredis.UniversalClient),
+	}
+}
+
+// 获取连接选项对应的客户端, 不存在时创建
+func (r *redisClients) Get(conf *config.RedisClient) rredis.UniversalClient {
+	key := fmt.Sprintf("%q %q %q %d", conf.Addrs, conf.Username, conf.Password, conf.DB)
+	if client, ok := r.shared[key]; ok {
+		return client
+	}
+
+	client := rredis.NewUniversalClient(&rredis.UniversalOptions{
+		Addrs:    conf.Addrs,
+		Username: conf.Username,
+		Password: conf.Password,
+		DB:       conf.DB,
+	})
+	r.clients[key] = client
+	r.shared[key] = sharedRedisClient{client}
+	return r.shared[key]
+}
+
+// 关闭所有客户端, 返回第一个错误
+func (r *redisClients) Close() error {
+	var err error
+	for _, client := range r.clients {
+		if e := client.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// 共用的redis客户端, 使用者关闭时不会关闭原始客户端
+type sharedRedisClient struct {
+	rredis.UniversalClient
+}
+
+func (sharedRedisClient) Close() error { return nil }
+
+// 根据配置创建单跑模块, redisClient 为缓存数据库的redis客户端, 缓存数据库不是redis时为nil
+func newSingleFlightFromConfig(conf *config.SingleFlight, redisClient rredis.UniversalClient, clients *redisClients) core.ISingleFlight {
+	switch conf.Type {
+	case config.SingleFlightNone:
+		return no_sf.NoSingleFlight()
+	case config.SingleFlightRedis:
+		rc := conf.Redis
+		if rc == nil {
+			rc = new(config.RedisSingleFlight)
+		}
+		if len(rc.Addrs) > 0 {
+			redisClient = clients.Get(&rc.RedisClient)
+		}
+		return redis_sf.NewRedisSingleFlight(redisClient,
+			redis_sf.WithKeyPrefix(rc.KeyPrefix),
+			redis_sf.WithLockTTL(rc.LockTTL.Duration()),
+			redis_sf.WithResultTTL(rc.ResultTTL.Duration()),
+			redis_sf.WithWaitTimeout(rc.WaitTimeout.Duration()),
+			redis_sf.WithPollInterval(rc.PollInterval.Duration()),
+		)
+	}
+	return single_sf.NewSingleFlightWithOptions(
+		single_sf.WithShardCount(conf.ShardCount),
+		single_sf.WithMaxWait(conf.MaxWait.Duration()),
+	)
+}
+
+// 将配置中的过期时间和随机增量转为 WithDefaultExpire 的参数
+func makeExpireFromConfig(expire, jitter config.Duration) (time.Duration, time.Duration) {
+	if jitter <= 0 {
+		return expire.Duration(), 0
+	}
+	return expire.Duration(), (expire + jitter).Duration()
+}
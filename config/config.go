@@ -0,0 +1,125 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package config
+
+// 缓存数据库类型
+const (
+	// 内存缓存(默认)
+	CacheDBMemory = "memory"
+	// redis
+	CacheDBRedis = "redis"
+)
+
+// 单跑模块类型
+const (
+	// 进程内单跑(默认)
+	SingleFlightSingle = "single"
+	// 不使用单跑
+	SingleFlightNone = "none"
+	// 通过redis锁让多个实例同一时间只有一个进程加载同一个数据
+	SingleFlightRedis = "redis"
+)
+
+// 缓存配置, 可以从json或yaml解析, 通过 zcache.NewCacheFromConfig 创建缓存
+type Config struct {
+	// 缓存数据库, 默认为内存缓存
+	CacheDB CacheDB `json:"cache_db" yaml:"cache_db"`
+	// 编解码器名, 比如 json, msgpack, 参考 codec.GetCodecByName. 为空时使用默认编解码器
+	Codec string `json:"codec" yaml:"codec"`
+	// 默认过期时间, <= 0 表示永不过期
+	DefaultExpire Duration `json:"default_expire" yaml:"default_expire"`
+	// 过期时间的随机增量, 过期时间在 [DefaultExpire, DefaultExpire+ExpireJitter) 区间随机
+	ExpireJitter Duration `json:"expire_jitter" yaml:"expire_jitter"`
+	// 在缓存故障时是否直接返回缓存错误, 为空时使用默认值
+	DirectReturnOnCacheFault *bool `json:"direct_return_on_cache_fault" yaml:"direct_return_on_cache_fault"`
+	// 单跑模块
+	SingleFlight SingleFlight `json:"single_flight" yaml:"single_flight"`
+	// bucket的配置, key为bucket名
+	Buckets map[string]Bucket `json:"buckets" yaml:"buckets"`
+}
+
+// 缓存数据库配置
+type CacheDB struct {
+	// 类型, memory 或 redis, 为空时为 memory
+	Type string `json:"type" yaml:"type"`
+	// 内存缓存的配置
+	Memory Memory `json:"memory" yaml:"memory"`
+	// redis的配置, Type 为 redis 时必须设置
+	Redis *Redis `json:"redis" yaml:"redis"`
+}
+
+// 内存缓存配置
+type Memory struct {
+	// 清除过期数据的间隔, 为0时使用默认值
+	CleanupInterval Duration `json:"cleanup_interval" yaml:"cleanup_interval"`
+}
+
+// redis客户端配置
+type RedisClient struct {
+	// 地址, 有多个地址时使用集群客户端
+	Addrs    []string `json:"addrs" yaml:"addrs"`
+	Username string   `json:"username" yaml:"username"`
+	Password string   `json:"password" yaml:"password"`
+	DB       int      `json:"db" yaml:"db"`
+}
+
+// redis缓存数据库配置
+type Redis struct {
+	RedisClient `yaml:",inline"`
+	// key前缀
+	KeyPrefix string `json:"key_prefix" yaml:"key_prefix"`
+	// 参数分隔符, 为空时使用默认值
+	ArgsSep string `json:"args_sep" yaml:"args_sep"`
+	// 操作超时时间, 为0时使用默认值
+	DoTimeout Duration `json:"do_timeout" yaml:"do_timeout"`
+	// 使用hash模式保存数据的bucket, 参考 redis_cache.WithHashBuckets
+	HashBuckets []string `json:"hash_buckets" yaml:"hash_buckets"`
+}
+
+// 单跑模块配置
+type SingleFlight struct {
+	// 类型, single, none 或 redis, 为空时为 single
+	Type string `json:"type" yaml:"type"`
+	// 进程内单跑的分片数, 必须为2的幂, 为0时使用默认值
+	ShardCount uint64 `json:"shard_count" yaml:"shard_count"`
+	// 进程内单跑等待其它调用者结果的最长时间, <= 0 表示一直等待
+	MaxWait Duration `json:"max_wait" yaml:"max_wait"`
+	// redis单跑的配置, Type 为 redis 时使用
+	Redis *RedisSingleFlight `json:"redis" yaml:"redis"`
+}
+
+// redis单跑配置, 未设置的时间使用默认值
+type RedisSingleFlight struct {
+	// redis客户端, 没有设置地址时使用缓存数据库的redis客户端
+	RedisClient `yaml:",inline"`
+	// key前缀
+	KeyPrefix string `json:"key_prefix" yaml:"key_prefix"`
+	// 锁的有效时间
+	LockTTL Duration `json:"lock_ttl" yaml:"lock_ttl"`
+	// 加载结果的保留时间
+	ResultTTL Duration `json:"result_ttl" yaml:"result_ttl"`
+	// 等待其它实例加载结果的最长时间
+	WaitTimeout Duration `json:"wait_timeout" yaml:"wait_timeout"`
+	// 等待时检查加载结果的间隔
+	PollInterval Duration `json:"poll_interval" yaml:"poll_interval"`
+}
+
+// bucket配置, 未设置的字段使用全局配置
+type Bucket struct {
+	// 缓存数据库
+	CacheDB *CacheDB `json:"cache_db" yaml:"cache_db"`
+	// 编解码器名
+	Codec string `json:"codec" yaml:"codec"`
+	// 默认过期时间, < 0 表示永不过期
+	Expire Duration `json:"expire" yaml:"expire"`
+	// 过期时间的随机增量
+	ExpireJitter Duration `json:"expire_jitter" yaml:"expire_jitter"`
+	// 在缓存故障时是否直接返回缓存错误
+	DirectReturnOnCacheFault *bool `json:"direct_return_on_cache_fault" yaml:"direct_return_on_cache_fault"`
+}
@@ -0,0 +1,130 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/zlyuancn/zcache/codec"
+	"github.com/zlyuancn/zcache/errs"
+)
+
+// 检查配置, 返回所有的错误
+//
+// 配置有效时返回nil, 否则返回 *errs.Errors, 每个错误都带有字段路径, 可以通过 errs.DecodeErrors 解包
+func (c *Config) Validate() error {
+	if c == nil {
+		return errs.NewErrors(errors.New("config is nil"))
+	}
+
+	v := &validator{errs: errs.NewErrors()}
+	v.cacheDB("cache_db", &c.CacheDB)
+	v.codec("codec", c.Codec)
+	v.expire("", c.DefaultExpire, c.ExpireJitter)
+	v.singleFlight("single_flight", &c.SingleFlight, c.CacheDB.Type == CacheDBRedis)
+
+	names := make([]string, 0, len(c.Buckets))
+	for name := range c.Buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := "buckets." + name
+		if name == "" {
+			v.add(path, "bucket name is empty")
+		}
+		b := c.Buckets[name]
+		if b.CacheDB != nil {
+			v.cacheDB(path+".cache_db", b.CacheDB)
+		}
+		v.codec(path+".codec", b.Codec)
+		v.expire(path+".", b.Expire, b.ExpireJitter)
+	}
+	return v.errs.Err()
+}
+
+type validator struct {
+	errs *errs.Errors
+}
+
+func (v *validator) add(path, format string, a ...interface{}) {
+	v.errs.AddErr(fmt.Errorf("%s: %s", path, fmt.Sprintf(format, a...)))
+}
+
+func (v *validator) cacheDB(path string, db *CacheDB) {
+	switch db.Type {
+	case "", CacheDBMemory:
+		if db.Memory.CleanupInterval < 0 {
+			v.add(path+".memory.cleanup_interval", "must not be negative")
+		}
+	case CacheDBRedis:
+		if db.Redis == nil {
+			v.add(path+".redis", "is required when type is redis")
+			return
+		}
+		v.redisClient(path+".redis", &db.Redis.RedisClient)
+		if db.Redis.DoTimeout < 0 {
+			v.add(path+".redis.do_timeout", "must not be negative")
+		}
+	default:
+		v.add(path+".type", "unknown cache db type %q", db.Type)
+	}
+}
+
+func (v *validator) redisClient(path string, c *RedisClient) {
+	if len(c.Addrs) == 0 {
+		v.add(path+".addrs", "is required")
+	}
+	for i, addr := range c.Addrs {
+		if addr == "" {
+			v.add(fmt.Sprintf("%s.addrs[%d]", path, i), "is empty")
+		}
+	}
+	if c.DB < 0 {
+		v.add(path+".db", "must not be negative")
+	}
+}
+
+func (v *validator) codec(path, name string) {
+	if name != "" && codec.GetCodecByName(name) == nil {
+		v.add(path, "unknown codec %q", name)
+	}
+}
+
+// prefix 为空或以 '.' 结尾, 全局配置和bucket配置的过期时间字段名不同
+func (v *validator) expire(prefix string, expire, jitter Duration) {
+	if jitter < 0 {
+		v.add(prefix+"expire_jitter", "must not be negative")
+	}
+	if jitter > 0 && expire <= 0 {
+		v.add(prefix+"expire_jitter", "requires a positive expire")
+	}
+}
+
+func (v *validator) singleFlight(path string, sf *SingleFlight, redisCacheDB bool) {
+	switch sf.Type {
+	case "", SingleFlightSingle:
+		if sf.ShardCount&(sf.ShardCount-1) != 0 {
+			v.add(path+".shard_count", "must be power of 2")
+		}
+	case SingleFlightNone:
+	case SingleFlightRedis:
+		if sf.Redis == nil || len(sf.Redis.Addrs) == 0 {
+			if !redisCacheDB {
+				v.add(path+".redis.addrs", "is required when cache db is not redis")
+			}
+			return
+		}
+		v.redisClient(path+".redis", &sf.Redis.RedisClient)
+	default:
+		v.add(path+".type", "unknown single flight type %q", sf.Type)
+	}
+}
@@ -9,6 +9,7 @@
 package core
 
 import (
+	"context"
 	"time"
 )
 
@@ -29,3 +30,45 @@ type ICacheDB interface {
 	// 关闭
 	Close() error
 }
+
+// 批量写入的数据
+type SetItem struct {
+	Query  IQuery
+	Data   []byte
+	Expire time.Duration // expire <= 0 时表示永不过期
+}
+
+// 支持批量写入的缓存数据库, 这是一个可选实现的接口
+type IMSetCacheDB interface {
+	// 设置多个值, 返回错误的数量必须和请求数量一致
+	MSet(items []SetItem) []error
+}
+
+// 支持上下文的缓存数据库, 这是一个可选实现的接口
+//
+// 上下文的取消和超时会传递到缓存数据库的操作中
+type IContextCacheDB interface {
+	ICacheDB
+
+	// 设置一个值, expire <= 0 时表示永不过期
+	SetWithContext(ctx context.Context, query IQuery, bs []byte, expire time.Duration) error
+	// 设置多个值, 返回错误的数量必须和请求数量一致
+	MSetWithContext(ctx context.Context, items []SetItem) []error
+	// 获取一个值, 如果缓存未命中请返回 errs.CacheMiss 错误
+	GetWithContext(ctx context.Context, query IQuery) ([]byte, error)
+	// 获取多个值, 返回数据和错误的数量必须和请求数量一致
+	MGetWithContext(ctx context.Context, queries ...IQuery) ([][]byte, []error)
+
+	// 删除数据
+	DelWithContext(ctx context.Context, queries ...IQuery) error
+	// 删除bucket
+	DelBucketWithContext(ctx context.Context, buckets ...string) error
+}
+
+// 可以在获取数据时返回剩余过期时间的缓存数据库, 这是一个可选实现的接口
+type ITTLCacheDB interface {
+	// 获取一个值和它的剩余过期时间, ttl <= 0 表示永不过期或无法获取. 如果缓存未命中请返回 errs.CacheMiss 错误
+	GetWithTTL(ctx context.Context, query IQuery) (bs []byte, ttl time.Duration, err error)
+	// 获取多个值和它们的剩余过期时间, 返回数据, 过期时间和错误的数量必须和请求数量一致
+	MGetWithTTL(ctx context.Context, queries ...IQuery) (buffs [][]byte, ttls []time.Duration, es []error)
+}
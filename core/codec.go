@@ -15,3 +15,11 @@ type ICodec interface {
 	// 解码
 	Decode(data []byte, a interface{}) error
 }
+
+// 有id的编解码器, 这是一个可选实现的接口
+//
+// 启用数据信封时, 编解码器的id会写入信封中
+type ICodecId interface {
+	// 编解码器id, 不能为0
+	CodecId() uint8
+}
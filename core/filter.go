@@ -0,0 +1,30 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package core
+
+import (
+	"context"
+)
+
+// 过滤器填充函数, 通过 add 将所有存在的数据的key添加到过滤器, key为query的ArgsText
+type FilterSeedFn = func(ctx context.Context, add func(keys ...string) error) error
+
+// 存在性过滤器, 在查询缓存数据库和加载器之前判断数据是否可能存在, 所有方法都可能被并发调用
+//
+// 过滤器可以误判数据存在, 但是不能误判数据不存在
+type IFilter interface {
+	// 添加数据的key, key为query的ArgsText
+	Add(ctx context.Context, keys ...string) error
+	// 检查数据是否可能存在, 返回结果的数量和顺序和keys一致
+	MayExist(ctx context.Context, keys ...string) ([]bool, error)
+	// 使用填充函数重建过滤器, 重建完成前会继续使用旧的数据
+	Rebuild(ctx context.Context, seed FilterSeedFn) error
+	// 关闭
+	Close() error
+}
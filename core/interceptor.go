@@ -0,0 +1,51 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package core
+
+import (
+	"context"
+)
+
+// 操作类型
+type Operation string
+
+const (
+	// 获取一条数据, 包括 Get 和 Query
+	OpGet Operation = "get"
+	// 批量获取数据
+	OpMQuery Operation = "mquery"
+	// 写入数据, 包括 Set, Save 和 MSave
+	OpSet Operation = "set"
+	// 删除数据, 包括 Remove 和 Del
+	OpDel Operation = "del"
+	// 删除桶
+	OpDelBucket Operation = "del_bucket"
+	// 从加载器加载数据并写入缓存
+	OpLoad Operation = "load"
+)
+
+// 一次操作的调用信息
+type Invocation struct {
+	// 操作类型
+	Op Operation
+	// 操作的query, OpDelBucket 时为空. 拦截器可以替换其中的query, 但不能改变数量
+	Queries []IQuery
+	// 操作的桶, 只有 OpDelBucket 时有值
+	Buckets []string
+	// 和 Queries 一一对应的原始数据, 这是编码后的数据.
+	//
+	// OpSet 在调用下一个处理器之前有值, 拦截器可以修改它. OpGet, OpMQuery 和 OpLoad 在调用下一个处理器之后有值.
+	Data [][]byte
+}
+
+// 操作处理器
+type Handler func(ctx context.Context, inv *Invocation) error
+
+// 拦截器, 必须调用 next 才会继续执行操作, 返回的错误会作为操作的结果
+type Interceptor func(ctx context.Context, inv *Invocation, next Handler) error
@@ -9,6 +9,7 @@
 package core
 
 import (
+	"context"
 	"time"
 )
 
@@ -24,3 +25,65 @@ type ILoader interface {
 	// 可以在这里设置随机有效时间防止缓存雪崩
 	Expire() (ex time.Duration)
 }
+
+// 可以在数据软过期后继续提供旧数据的加载器, 这是一个可选实现的接口
+type IStaleLoader interface {
+	// 软过期后仍然可以提供旧数据的时间窗口
+	//
+	// 数据在加载器设置的过期时间后进入软过期, 在这个时间窗口内读取数据会立即返回旧数据, 同时在后台刷新数据.
+	// 超过这个时间窗口后数据才真正过期. 返回值 <= 0 表示不启用.
+	StaleWindow() time.Duration
+}
+
+// 可以在加载失败时使用过期数据的加载器, 这是一个可选实现的接口
+type IGraceLoader interface {
+	// 过期数据的保留时间
+	//
+	// 数据过期后会在缓存中继续保留这段时间, 在这段时间内如果加载器加载数据失败, 会返回过期的数据. 返回值 <= 0 表示不启用.
+	GracePeriod() time.Duration
+}
+
+// 可以在数据过期前提前刷新的加载器, 这是一个可选实现的接口
+type IEarlyRefreshLoader interface {
+	// 提前刷新的系数
+	//
+	// 使用 XFetch 算法, 数据快要过期时每次读取都有一定概率在后台刷新数据, 加载耗时越长, 系数越大, 越早开始刷新.
+	// 返回值 > 0 表示使用这个系数, = 0 表示使用全局设置, < 0 表示不启用
+	EarlyRefreshBeta() float64
+}
+
+// 可以单独设置数据不存在标记的过期时间的加载器, 这是一个可选实现的接口
+type INotFoundLoader interface {
+	// 加载器返回 errs.NotFound 时, 数据不存在标记的过期时间
+	//
+	// 返回值 > 0 表示使用这个过期时间, = 0 表示使用全局设置, < 0 表示不缓存数据不存在标记
+	NotFoundExpire() time.Duration
+}
+
+// 批量加载器, 这是一个可选实现的接口
+//
+// 批量获取数据时, 缓存未命中的query会通过 LoadMany 一次性加载
+type IBatchLoader interface {
+	ILoader
+	// 批量加载数据
+	//
+	// 返回的map的key为数据在queries中的索引, 结果中不存在的索引视为加载器返回了nil,
+	// 值为 errs.NotFound 表示这条数据不存在, 值为其它错误表示这条数据加载失败. 返回错误时所有query都会加载失败
+	LoadMany(queries []IQuery) (map[int]interface{}, error)
+	// 每次调用 LoadMany 的最大query数量, <= 0 表示不限制
+	BatchSize() int
+}
+
+// 支持上下文的加载器, 这是一个可选实现的接口
+type IContextLoader interface {
+	ILoader
+	// 加载数据, 上下文来自调用者
+	LoadWithContext(ctx context.Context, query IQuery) (interface{}, error)
+}
+
+// 支持上下文的批量加载器, 这是一个可选实现的接口
+type IContextBatchLoader interface {
+	IBatchLoader
+	// 批量加载数据, 上下文来自调用者
+	LoadManyWithContext(ctx context.Context, queries []IQuery) (map[int]interface{}, error)
+}
@@ -30,3 +30,12 @@ type IQuery interface {
 	// 设置错误
 	SetError(err error)
 }
+
+// 可以记录返回的数据是否为过期数据的查询参数, 这是一个可选实现的接口
+type IStaleQuery interface {
+	IQuery
+	// 返回的数据是否为加载失败时使用的过期数据
+	IsStale() bool
+	// 设置是否为过期数据
+	SetStale(stale bool)
+}
@@ -8,6 +8,33 @@
 
 package core
 
+import (
+	"context"
+)
+
 type ISingleFlight interface {
 	Do(query IQuery, fn func(query IQuery) ([]byte, error)) ([]byte, error)
 }
+
+// 可以批量执行的单跑模块, 这是一个可选实现的接口
+type IBatchSingleFlight interface {
+	ISingleFlight
+	// 批量执行, 只有没有其它调用者在执行的query才会传入fn, 其它的query会等待其它调用者的结果
+	//
+	// fn返回的数据和错误的数量必须和传入的query数量一致, 返回数据和错误的数量和请求数量一致
+	DoBatch(queries []IQuery, fn func(queries []IQuery) ([][]byte, []error)) ([][]byte, []error)
+}
+
+// 支持上下文的单跑模块, 这是一个可选实现的接口
+type IContextSingleFlight interface {
+	ISingleFlight
+	// 执行, fn会收到第一个调用者的上下文中的值, 所有调用者都放弃等待后fn的上下文应该结束. 等待其它调用者的结果时, 如果ctx结束会立即返回ctx的错误
+	DoWithContext(ctx context.Context, query IQuery, fn func(ctx context.Context, query IQuery) ([]byte, error)) ([]byte, error)
+}
+
+// 支持上下文的批量单跑模块, 这是一个可选实现的接口
+type IContextBatchSingleFlight interface {
+	IContextSingleFlight
+	// 批量执行, 同 IBatchSingleFlight.DoBatch
+	DoBatchWithContext(ctx context.Context, queries []IQuery, fn func(ctx context.Context, queries []IQuery) ([][]byte, []error)) ([][]byte, []error)
+}
@@ -0,0 +1,29 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package core
+
+import (
+	"time"
+)
+
+// 统计收集器, 所有方法都可能被并发调用
+type IStats interface {
+	// 缓存命中
+	Hit(bucket string)
+	// 缓存未命中, 需要从加载器加载数据
+	Miss(bucket string)
+	// 调用了加载器, latency 为加载耗时, err 为加载错误
+	Load(bucket string, latency time.Duration, err error)
+	// 缓存数据库返回了错误, 不包括缓存未命中
+	CacheError(bucket string, err error)
+	// 没有调用加载器, 而是等待了其它调用者的加载结果
+	SharedWait(bucket string)
+	// 数据解码失败
+	DecodeError(bucket string, err error)
+}
@@ -0,0 +1,63 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package core
+
+import (
+	"context"
+)
+
+// span名
+const (
+	// 从缓存数据库获取一条数据
+	SpanCacheGet = "zcache.cache.get"
+	// 从缓存数据库批量获取数据
+	SpanCacheMGet = "zcache.cache.mget"
+	// 写入缓存数据库
+	SpanCacheSet = "zcache.cache.set"
+	// 通过单跑模块执行加载, 包括等待其它调用者的结果
+	SpanSingleFlight = "zcache.singleflight"
+	// 调用加载器
+	SpanLoad = "zcache.load"
+	// 编码
+	SpanEncode = "zcache.codec.encode"
+	// 解码
+	SpanDecode = "zcache.codec.decode"
+)
+
+// span属性名
+const (
+	// 桶名
+	AttrBucket = "zcache.bucket"
+	// query的ArgsText
+	AttrArgs = "zcache.args"
+	// 是否命中缓存
+	AttrHit = "zcache.hit"
+	// 批量操作的数据数量
+	AttrCount = "zcache.count"
+	// 批量操作命中缓存的数量
+	AttrHits = "zcache.hits"
+	// 等待了其它调用者的加载结果的数量
+	AttrShared = "zcache.shared"
+)
+
+// 链路追踪
+type ITracer interface {
+	// 开始一个span, 返回的ctx中带有这个span, 使用这个ctx开始的span是它的子span
+	Start(ctx context.Context, name string) (context.Context, ISpan)
+}
+
+// 链路追踪的一个span
+type ISpan interface {
+	// 设置属性, value 为 string, bool, int 等基础类型
+	SetAttribute(key string, value interface{})
+	// 记录错误, err 为 nil 时忽略
+	RecordError(err error)
+	// 结束
+	End()
+}
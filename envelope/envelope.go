@@ -0,0 +1,169 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package envelope
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+)
+
+// 信封格式的魔数, 以 0x00 开头的数据不会是 json 或 protobuf 的编码结果
+var magic = []byte{0x00, 'z', 'c'}
+
+// 当前格式版本
+const Version byte = 2
+
+// 校验和的长度
+const checksumLen = 4
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// 头部字段标记
+const (
+	tagSoftExpireAt uint64 = 1 // 软过期时间戳
+	tagExpireAt     uint64 = 2 // 过期时间戳
+	tagFlags        uint64 = 3 // 标记位
+	tagFreshUntil   uint64 = 4 // 数据保持新鲜的截止时间戳
+	tagLoadDuration uint64 = 5 // 加载耗时
+	tagCreatedAt    uint64 = 6 // 写入时间戳
+	tagCodecId      uint64 = 7 // 编解码器id
+)
+
+// 标记位
+const (
+	FlagNotFound int64 = 1 << 0 // 数据不存在, 用于缓存加载器返回的 NotFound
+)
+
+// 数据信封, 在缓存数据前面附带一些元数据
+//
+// 格式: magic(3字节) + 版本(1字节) + 头部长度(uvarint) + 头部 + 校验和(4字节) + 数据.
+// 头部由若干个 tag(uvarint) + value(varint) 组成, 读取时会忽略不认识的 tag.
+// 校验和为校验和之前所有字节的 crc32c, 没有启用信封时写入的数据即使以魔数开头也几乎不可能通过校验, 不会被误认为信封
+type Envelope struct {
+	SoftExpireAt int64 // 软过期时间戳(纳秒), 0表示没有软过期
+	ExpireAt     int64 // 过期时间戳(纳秒), 过期后的数据只在加载失败时使用, 0表示由缓存数据库控制过期
+	Flags        int64 // 标记位
+	FreshUntil   int64 // 数据保持新鲜的截止时间戳(纳秒), 也就是加载器设置的过期时间, 用于提前刷新
+	LoadDuration int64 // 最后一次加载数据的耗时(纳秒), 用于提前刷新
+	CreatedAt    int64 // 写入时间戳(纳秒)
+	CodecId      uint8 // 编码数据的编解码器id, 0表示未知
+
+	Data []byte // 编码后的数据
+}
+
+// 是否为数据不存在的标记
+func (e *Envelope) IsNotFound() bool {
+	return e.Flags&FlagNotFound != 0
+}
+
+// 检查数据是否为信封格式
+func IsEnvelope(bs []byte) bool {
+	_, ok := decode(bs)
+	return ok
+}
+
+// 编码
+func Encode(e *Envelope) []byte {
+	header := make([]byte, 0, binary.MaxVarintLen64*8)
+	header = appendField(header, tagSoftExpireAt, e.SoftExpireAt)
+	header = appendField(header, tagExpireAt, e.ExpireAt)
+	header = appendField(header, tagFlags, e.Flags)
+	header = appendField(header, tagFreshUntil, e.FreshUntil)
+	header = appendField(header, tagLoadDuration, e.LoadDuration)
+	header = appendField(header, tagCreatedAt, e.CreatedAt)
+	header = appendField(header, tagCodecId, int64(e.CodecId))
+
+	buff := make([]byte, 0, len(magic)+1+binary.MaxVarintLen64+len(header)+checksumLen+len(e.Data))
+	buff = append(buff, magic...)
+	buff = append(buff, Version)
+	buff = appendUvarint(buff, uint64(len(header)))
+	buff = append(buff, header...)
+	var sum [checksumLen]byte
+	binary.BigEndian.PutUint32(sum[:], crc32.Checksum(buff, crcTable))
+	buff = append(buff, sum[:]...)
+	buff = append(buff, e.Data...)
+	return buff
+}
+
+// 解码, 如果数据不是信封格式, 会将整个数据作为 Data 返回
+func Decode(bs []byte) *Envelope {
+	if e, ok := decode(bs); ok {
+		return e
+	}
+	return &Envelope{Data: bs}
+}
+
+// 解码, 魔数, 版本或校验和不匹配时返回 false
+func decode(bs []byte) (*Envelope, bool) {
+	if len(bs) <= len(magic) || !bytes.Equal(bs[:len(magic)], magic) || bs[len(magic)] != Version {
+		return nil, false
+	}
+
+	rest := bs[len(magic)+1:]
+	headerLen, n := binary.Uvarint(rest)
+	if n <= 0 || uint64(len(rest)-n) < headerLen+checksumLen {
+		return nil, false
+	}
+	headerEnd := len(bs) - len(rest) + n + int(headerLen)
+	if crc32.Checksum(bs[:headerEnd], crcTable) != binary.BigEndian.Uint32(bs[headerEnd:]) {
+		return nil, false
+	}
+	header, data := bs[headerEnd-int(headerLen):headerEnd], bs[headerEnd+checksumLen:]
+
+	e := &Envelope{Data: data}
+	for len(header) > 0 {
+		tag, n := binary.Uvarint(header)
+		if n <= 0 {
+			return nil, false
+		}
+		header = header[n:]
+
+		value, n := binary.Varint(header)
+		if n <= 0 {
+			return nil, false
+		}
+		header = header[n:]
+
+		switch tag {
+		case tagSoftExpireAt:
+			e.SoftExpireAt = value
+		case tagExpireAt:
+			e.ExpireAt = value
+		case tagFlags:
+			e.Flags = value
+		case tagFreshUntil:
+			e.FreshUntil = value
+		case tagLoadDuration:
+			e.LoadDuration = value
+		case tagCreatedAt:
+			e.CreatedAt = value
+		case tagCodecId:
+			e.CodecId = uint8(value)
+		}
+	}
+	return e, true
+}
+
+// 写入一个字段, 值为0时不写入
+func appendField(buff []byte, tag uint64, value int64) []byte {
+	if value == 0 {
+		return buff
+	}
+	buff = appendUvarint(buff, tag)
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(b[:], value)
+	return append(buff, b[:n]...)
+}
+
+func appendUvarint(buff []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	return append(buff, b[:n]...)
+}
@@ -20,3 +20,6 @@ var CacheMiss = errors.New("cache miss")
 
 // 数据为nil
 var DataIsNil = errors.New("data is nil")
+
+// 数据不存在, 加载器返回这个错误时会在缓存中写入一个数据不存在的标记, 之后的查询会直接返回这个错误
+var NotFound = errors.New("not found")
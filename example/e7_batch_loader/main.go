@@ -0,0 +1,38 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/zlyuancn/zcache"
+)
+
+func main() {
+	cache := zcache.NewCache()
+
+	// 注册批量加载函数, 批量获取时所有缓存未命中的query会一次性传入
+	cache.RegisterBatchLoaderFn("test", func(queries []zcache.IQuery) (map[int]interface{}, error) {
+		fmt.Println("批量加载", len(queries))
+		results := make(map[int]interface{}, len(queries))
+		for i, q := range queries {
+			results[i] = "hello" + q.ArgsText() // 结果的key为query的索引
+		}
+		return results, nil
+	}, zcache.WithLoaderBatchSize(100)) // 每批最多100个query
+
+	var results []string
+	_ = cache.MQuery("test", &results,
+		zcache.QC().Args("world1"),
+		zcache.QC().Args("world2"),
+		zcache.QC().Args("world3"),
+	)
+
+	fmt.Println(results)
+}
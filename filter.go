@@ -0,0 +1,139 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package zcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zlyuancn/zcache/cachedb"
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/errs"
+	"github.com/zlyuancn/zcache/query"
+)
+
+// 注册存在性过滤器, 获取这个bucket的数据前会先检查过滤器, 过滤器判断数据不存在时直接返回 errs.NotFound
+//
+// 通过 Set, Save, MSave 写入的数据会自动添加到过滤器, 其它存在的数据需要通过 AddToFilter 或过滤器的填充函数添加
+func (c *Cache) RegisterFilter(bucket string, f core.IFilter) {
+	if bucket == "" {
+		panic(errors.New("bucket name is empty"))
+	}
+
+	c.filterLock.Lock()
+	c.filters[bucket] = f
+	c.filterLock.Unlock()
+}
+
+// 获取过滤器, 过滤器不存在时返回nil
+func (c *Cache) getFilter(bucket string) core.IFilter {
+	c.filterLock.RLock()
+	f := c.filters[bucket]
+	c.filterLock.RUnlock()
+	return f
+}
+
+// 添加数据到过滤器, args 为数据的查询参数, 未注册过滤器时忽略
+func (c *Cache) AddToFilter(bucket string, args ...interface{}) error {
+	return c.AddToFilterWithContext(nil, bucket, args...)
+}
+
+// 添加数据到过滤器, args 为数据的查询参数, 未注册过滤器时忽略
+func (c *Cache) AddToFilterWithContext(ctx context.Context, bucket string, args ...interface{}) error {
+	f := c.getFilter(bucket)
+	if f == nil || len(args) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(args))
+	for i, a := range args {
+		keys[i] = query.ArgsText(a)
+	}
+	return c.doWithContext(ctx, func(ctx context.Context) error {
+		return f.Add(ctx, keys...)
+	})
+}
+
+// 将写入缓存的数据添加到过滤器, 失败时只记录日志
+func (c *Cache) addToFilter(ctx context.Context, queries ...core.IQuery) {
+	buckets, groups := cachedb.GroupBy(len(queries), func(i int) string { return queries[i].Bucket() })
+	for g, bucket := range buckets {
+		qs := groups[g]
+		f := c.getFilter(bucket)
+		if f == nil {
+			continue
+		}
+
+		keys := make([]string, len(qs))
+		for i, q := range qs {
+			keys[i] = queries[q].ArgsText()
+		}
+		if err := f.Add(ctx, keys...); err != nil {
+			c.log.Error(fmt.Errorf("add to filter error. bucket: %s, err: %s", bucket, err))
+		}
+	}
+}
+
+// 使用过滤器检查数据是否可能存在, 返回可能存在的query, 不存在的query会设置 errs.NotFound 错误
+//
+// 过滤器出错时视为数据可能存在
+func (c *Cache) filterQueries(ctx context.Context, queries []core.IQuery) []core.IQuery {
+	c.filterLock.RLock()
+	n := len(c.filters)
+	c.filterLock.RUnlock()
+	if n == 0 {
+		return queries
+	}
+
+	var notExists map[int]bool
+	buckets, groups := cachedb.GroupBy(len(queries), func(i int) string { return queries[i].Bucket() })
+	for g, bucket := range buckets {
+		indexes := groups[g]
+		f := c.getFilter(bucket)
+		if f == nil {
+			continue
+		}
+
+		keys := make([]string, len(indexes))
+		for i, index := range indexes {
+			keys[i] = queries[index].ArgsText()
+		}
+		exists, err := f.MayExist(ctx, keys...)
+		if err == nil && len(exists) != len(keys) {
+			err = errors.New("filter result is inconsistent with the number of requests")
+		}
+		if err != nil {
+			c.log.Error(fmt.Errorf("check filter error, the data will be treated as existing. bucket: %s, err: %s", bucket, err))
+			continue
+		}
+
+		for i, index := range indexes {
+			if exists[i] {
+				continue
+			}
+			if notExists == nil {
+				notExists = make(map[int]bool)
+			}
+			notExists[index] = true
+			queries[index].SetError(errs.NotFound)
+		}
+	}
+	if len(notExists) == 0 {
+		return queries
+	}
+
+	result := make([]core.IQuery, 0, len(queries)-len(notExists))
+	for i, q := range queries {
+		if !notExists[i] {
+			result = append(result, q)
+		}
+	}
+	return result
+}
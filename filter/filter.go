@@ -0,0 +1,88 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package filter
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/zlyuancn/zcache/core"
+)
+
+const (
+	// 默认预计数据量
+	DefaultCapacity = 1000000
+	// 默认误判率
+	DefaultFalsePositiveRate = 0.001
+	// 默认重建超时时间
+	DefaultRebuildTimeout = time.Minute * 10
+)
+
+// 根据预计数据量和误判率计算布隆过滤器的位数和哈希函数数量
+func EstimateParameters(capacity uint64, falsePositiveRate float64) (m uint64, k uint64) {
+	if capacity == 0 {
+		capacity = DefaultCapacity
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = DefaultFalsePositiveRate
+	}
+
+	m = uint64(math.Ceil(-float64(capacity) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	k = uint64(math.Ceil(math.Ln2 * float64(m) / float64(capacity)))
+	if m == 0 {
+		m = 1
+	}
+	if k == 0 {
+		k = 1
+	}
+	return m, k
+}
+
+// 计算key在布隆过滤器中的k个位置, 位置在 [0, m) 区间
+//
+// 使用双重哈希 h1 + i*h2 模拟k个哈希函数
+func Locations(key string, m, k uint64) []uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	h1 := h.Sum64()
+	h2 := h1>>33 | h1<<31
+	h2 ^= h2 >> 29
+	h2 *= 0xbf58476d1ce4e5b9
+	h2 |= 1 // 保证为奇数, 避免所有位置相同
+
+	locations := make([]uint64, k)
+	for i := uint64(0); i < k; i++ {
+		locations[i] = (h1 + i*h2) % m
+	}
+	return locations
+}
+
+// 每隔 interval 时间调用一次 rebuild, 直到ctx结束. 每次调用的超时时间为 timeout
+func RebuildLoop(ctx context.Context, interval, timeout time.Duration, rebuild func(ctx context.Context) error, log core.ILogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		rebuildCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := rebuild(rebuildCtx)
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			log.Error(fmt.Errorf("rebuild filter error: %s", err))
+		}
+	}
+}
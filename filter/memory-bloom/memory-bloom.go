@@ -0,0 +1,173 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package memory_bloom
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/filter"
+	"github.com/zlyuancn/zcache/logger"
+)
+
+type bitset struct {
+	bits []uint64
+	m, k uint64
+}
+
+func newBitset(m, k uint64) *bitset {
+	return &bitset{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+func (b *bitset) add(key string) {
+	for _, loc := range filter.Locations(key, b.m, b.k) {
+		b.bits[loc/64] |= 1 << (loc % 64)
+	}
+}
+
+func (b *bitset) has(key string) bool {
+	for _, loc := range filter.Locations(key, b.m, b.k) {
+		if b.bits[loc/64]&(1<<(loc%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+var _ core.IFilter = (*MemoryBloom)(nil)
+
+// 内存布隆过滤器
+type MemoryBloom struct {
+	capacity          uint64
+	falsePositiveRate float64
+	seed              core.FilterSeedFn
+	rebuildInterval   time.Duration
+	rebuildTimeout    time.Duration
+	log               core.ILogger
+
+	mx         sync.RWMutex
+	current    *bitset
+	rebuilding *bitset // 正在重建的数据, 重建期间添加的key会同时写入这里
+	ready      bool    // 设置了填充函数时, 第一次填充完成前所有数据都视为可能存在
+	rebuildMx  sync.Mutex
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+}
+
+// 创建一个内存布隆过滤器
+//
+// 如果设置了填充函数, 会立即在后台填充数据, 第一次填充完成前所有数据都视为可能存在
+func NewMemoryBloom(opts ...Option) *MemoryBloom {
+	b := &MemoryBloom{
+		capacity:          filter.DefaultCapacity,
+		falsePositiveRate: filter.DefaultFalsePositiveRate,
+		rebuildTimeout:    filter.DefaultRebuildTimeout,
+	}
+	for _, o := range opts {
+		o(b)
+	}
+	if b.log == nil {
+		b.log = logger.NoLog()
+	}
+
+	b.current = b.newBitset()
+	b.ready = b.seed == nil
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	if b.seed != nil {
+		go b.start()
+	}
+	return b
+}
+
+func (b *MemoryBloom) newBitset() *bitset {
+	m, k := filter.EstimateParameters(b.capacity, b.falsePositiveRate)
+	return newBitset(m, k)
+}
+
+// 第一次填充数据, 然后定时重建
+func (b *MemoryBloom) start() {
+	rebuild := func(ctx context.Context) error {
+		return b.Rebuild(ctx, b.seed)
+	}
+
+	ctx, cancel := context.WithTimeout(b.ctx, b.rebuildTimeout)
+	err := rebuild(ctx)
+	cancel()
+	if err != nil && b.ctx.Err() == nil {
+		b.log.Error(fmt.Errorf("seed filter error: %s", err))
+	}
+
+	if b.rebuildInterval > 0 {
+		filter.RebuildLoop(b.ctx, b.rebuildInterval, b.rebuildTimeout, rebuild, b.log)
+	}
+}
+
+func (b *MemoryBloom) Add(_ context.Context, keys ...string) error {
+	b.mx.Lock()
+	for _, key := range keys {
+		b.current.add(key)
+		if b.rebuilding != nil {
+			b.rebuilding.add(key)
+		}
+	}
+	b.mx.Unlock()
+	return nil
+}
+
+func (b *MemoryBloom) MayExist(_ context.Context, keys ...string) ([]bool, error) {
+	result := make([]bool, len(keys))
+	b.mx.RLock()
+	for i, key := range keys {
+		result[i] = !b.ready || b.current.has(key)
+	}
+	b.mx.RUnlock()
+	return result, nil
+}
+
+// 使用填充函数重建过滤器, 同时只会有一个重建任务
+func (b *MemoryBloom) Rebuild(ctx context.Context, seed core.FilterSeedFn) error {
+	b.rebuildMx.Lock()
+	defer b.rebuildMx.Unlock()
+
+	bs := b.newBitset()
+	b.mx.Lock()
+	b.rebuilding = bs
+	b.mx.Unlock()
+
+	err := seed(ctx, func(keys ...string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		b.mx.Lock()
+		for _, key := range keys {
+			bs.add(key)
+		}
+		b.mx.Unlock()
+		return nil
+	})
+
+	b.mx.Lock()
+	b.rebuilding = nil
+	if err == nil {
+		b.current, b.ready = bs, true
+	}
+	b.mx.Unlock()
+	return err
+}
+
+// 停止定时重建
+func (b *MemoryBloom) Close() error {
+	b.closeOnce.Do(b.cancel)
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package memory_bloom
+
+import (
+	"time"
+
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/filter"
+	"github.com/zlyuancn/zcache/logger"
+)
+
+type Option func(b *MemoryBloom)
+
+// 设置预计数据量, 数据量超过这个值后误判率会上升
+func WithCapacity(capacity uint64) Option {
+	return func(b *MemoryBloom) {
+		if capacity == 0 {
+			capacity = filter.DefaultCapacity
+		}
+		b.capacity = capacity
+	}
+}
+
+// 设置误判率, 有效值在 (0, 1) 区间
+func WithFalsePositiveRate(rate float64) Option {
+	return func(b *MemoryBloom) {
+		if rate <= 0 || rate >= 1 {
+			rate = filter.DefaultFalsePositiveRate
+		}
+		b.falsePositiveRate = rate
+	}
+}
+
+// 设置填充函数, 创建后会立即在后台填充数据
+//
+// 如果 rebuildInterval > 0, 会每隔 rebuildInterval 时间使用填充函数重建过滤器
+func WithSeed(seed core.FilterSeedFn, rebuildInterval ...time.Duration) Option {
+	return func(b *MemoryBloom) {
+		b.seed, b.rebuildInterval = seed, 0
+		if len(rebuildInterval) > 0 {
+			b.rebuildInterval = rebuildInterval[0]
+		}
+	}
+}
+
+// 设置后台填充和重建的超时时间
+func WithRebuildTimeout(timeout time.Duration) Option {
+	return func(b *MemoryBloom) {
+		if timeout <= 0 {
+			timeout = filter.DefaultRebuildTimeout
+		}
+		b.rebuildTimeout = timeout
+	}
+}
+
+// 设置日志组件
+func WithLogger(log core.ILogger) Option {
+	return func(b *MemoryBloom) {
+		if log == nil {
+			log = logger.NoLog()
+		}
+		b.log = log
+	}
+}
@@ -0,0 +1,276 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package redis_bloom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	rredis "github.com/go-redis/redis/v8"
+
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/filter"
+	"github.com/zlyuancn/zcache/logger"
+)
+
+// 默认操作超时时间
+const DefaultDoTimeout = time.Second * 5
+
+// 第一次填充时没有抢到锁的实例检查填充结果的间隔
+const initPollInterval = time.Millisecond * 50
+
+// 设置位, 如果正在重建, 同时写入重建中的key
+var addScript = rredis.NewScript(`
+local rebuilding = redis.call("exists", KEYS[2]) == 1
+for i = 1, #ARGV do
+	redis.call("setbit", KEYS[1], ARGV[i], 1)
+	if rebuilding then
+		redis.call("setbit", KEYS[2], ARGV[i], 1)
+	end
+end
+return 0
+`)
+
+var _ core.IFilter = (*RedisBloom)(nil)
+
+// 基于 redis 位图的布隆过滤器, 多个实例可以共享同一个过滤器
+//
+// 重建时会先写入 key+":rebuilding", 完成后替换 key. 集群模式下 key 需要使用 hash tag, 比如 {user}:bloom
+type RedisBloom struct {
+	client                   rredis.UniversalClient
+	key, rebuildKey, lockKey string
+	m, k                     uint64
+
+	capacity          uint64
+	falsePositiveRate float64
+	seed              core.FilterSeedFn
+	rebuildInterval   time.Duration
+	rebuildTimeout    time.Duration
+	doTimeout         time.Duration
+	log               core.ILogger
+
+	mx    sync.RWMutex
+	ready bool // 设置了填充函数时, 第一次填充完成前所有数据都视为可能存在
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+}
+
+// 创建一个 redis 布隆过滤器
+//
+// 如果设置了填充函数且 key 不存在, 会立即在后台填充数据, 第一次填充完成前所有数据都视为可能存在.
+// 定时重建时多个实例中每个周期只会有一个实例执行重建
+func NewRedisBloom(client rredis.UniversalClient, key string, opts ...Option) *RedisBloom {
+	if key == "" {
+		panic(errors.New("key of redis bloom is empty"))
+	}
+
+	b := &RedisBloom{
+		client:     client,
+		key:        key,
+		rebuildKey: key + ":rebuilding",
+		lockKey:    key + ":lock",
+
+		capacity:          filter.DefaultCapacity,
+		falsePositiveRate: filter.DefaultFalsePositiveRate,
+		rebuildTimeout:    filter.DefaultRebuildTimeout,
+		doTimeout:         DefaultDoTimeout,
+	}
+	for _, o := range opts {
+		o(b)
+	}
+	if b.log == nil {
+		b.log = logger.NoLog()
+	}
+
+	b.m, b.k = filter.EstimateParameters(b.capacity, b.falsePositiveRate)
+	b.ready = b.seed == nil
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	if b.seed != nil {
+		go b.start()
+	}
+	return b
+}
+
+// 第一次填充数据, 然后定时重建
+func (b *RedisBloom) start() {
+	ctx, cancel := context.WithTimeout(b.ctx, b.rebuildTimeout)
+	err := b.init(ctx)
+	cancel()
+	if err != nil && b.ctx.Err() == nil {
+		b.log.Error(fmt.Errorf("seed filter error: %s", err))
+	}
+
+	if b.rebuildInterval > 0 {
+		filter.RebuildLoop(b.ctx, b.rebuildInterval, b.rebuildTimeout, b.tryRebuild, b.log)
+	}
+}
+
+// key已经存在时直接使用, 否则抢锁填充数据, 没有抢到锁的实例等待其它实例填充完成
+//
+// 多个实例同时启动时共用 rebuildKey, 只能有一个实例填充, 否则会得到不完整的位图
+func (b *RedisBloom) init(ctx context.Context) error {
+	for {
+		n, err := b.client.Exists(ctx, b.key).Result()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			b.mx.Lock()
+			b.ready = true
+			b.mx.Unlock()
+			return nil
+		}
+
+		ok, err := b.client.SetNX(ctx, b.lockKey, 1, b.rebuildTimeout).Result()
+		if err != nil {
+			return err
+		}
+		if ok {
+			err = b.Rebuild(ctx, b.seed)
+			if err != nil { // 释放锁让其它实例重试
+				b.client.Del(context.Background(), b.lockKey)
+			}
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(initPollInterval):
+		}
+	}
+}
+
+// 抢到锁时重建, 锁不会主动释放, 保证一个周期内只有一个实例重建
+func (b *RedisBloom) tryRebuild(ctx context.Context) error {
+	ok, err := b.client.SetNX(ctx, b.lockKey, 1, b.rebuildInterval*9/10).Result()
+	if err != nil || !ok {
+		return err
+	}
+	return b.Rebuild(ctx, b.seed)
+}
+
+// 获取所有key在位图中的位置, 每个key有k个位置
+func (b *RedisBloom) locations(keys []string) []int64 {
+	locations := make([]int64, 0, len(keys)*int(b.k))
+	for _, key := range keys {
+		for _, loc := range filter.Locations(key, b.m, b.k) {
+			locations = append(locations, int64(loc))
+		}
+	}
+	return locations
+}
+
+func (b *RedisBloom) Add(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.doTimeout)
+	defer cancel()
+	locations := b.locations(keys)
+	args := make([]interface{}, len(locations))
+	for i, loc := range locations {
+		args[i] = loc
+	}
+	err := addScript.Run(ctx, b.client, []string{b.key, b.rebuildKey}, args...).Err()
+	if err == rredis.Nil {
+		return nil
+	}
+	return err
+}
+
+func (b *RedisBloom) MayExist(ctx context.Context, keys ...string) ([]bool, error) {
+	result := make([]bool, len(keys))
+	b.mx.RLock()
+	ready := b.ready
+	b.mx.RUnlock()
+	if !ready {
+		for i := range result {
+			result[i] = true
+		}
+		return result, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.doTimeout)
+	defer cancel()
+
+	// 使用管道一次性读取
+	pipe := b.client.Pipeline()
+	cmds := make([]*rredis.IntCmd, 0, len(keys)*int(b.k))
+	for _, loc := range b.locations(keys) {
+		cmds = append(cmds, pipe.GetBit(ctx, b.key, loc))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	for i := range keys {
+		result[i] = true
+		for _, cmd := range cmds[i*int(b.k) : (i+1)*int(b.k)] {
+			if cmd.Val() == 0 {
+				result[i] = false
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// 使用填充函数重建过滤器
+func (b *RedisBloom) Rebuild(ctx context.Context, seed core.FilterSeedFn) error {
+	// 创建重建中的key, 之后通过 Add 添加的数据会同时写入这个key
+	pipe := b.client.TxPipeline()
+	pipe.Del(ctx, b.rebuildKey)
+	pipe.SetBit(ctx, b.rebuildKey, int64(b.m-1), 0)
+	pipe.PExpire(ctx, b.rebuildKey, b.rebuildTimeout)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	err := seed(ctx, func(keys ...string) error {
+		if len(keys) == 0 {
+			return nil
+		}
+		pipe := b.client.Pipeline()
+		for _, loc := range b.locations(keys) {
+			pipe.SetBit(ctx, b.rebuildKey, loc, 1)
+		}
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+	if err != nil {
+		b.client.Del(context.Background(), b.rebuildKey)
+		return err
+	}
+
+	// 替换
+	pipe = b.client.TxPipeline()
+	pipe.Rename(ctx, b.rebuildKey, b.key)
+	pipe.Persist(ctx, b.key)
+	if _, err = pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	b.mx.Lock()
+	b.ready = true
+	b.mx.Unlock()
+	return nil
+}
+
+// 停止定时重建
+func (b *RedisBloom) Close() error {
+	b.closeOnce.Do(b.cancel)
+	return nil
+}
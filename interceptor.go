@@ -0,0 +1,46 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package zcache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/zlyuancn/zcache/core"
+)
+
+// 通过拦截器链执行操作, 第一个拦截器在最外层
+func (c *Cache) intercept(ctx context.Context, inv *core.Invocation, handler core.Handler) error {
+	if len(c.interceptors) == 0 {
+		return handler(ctx, inv)
+	}
+	return c.interceptors[0](ctx, inv, c.nextHandler(1, handler))
+}
+
+func (c *Cache) nextHandler(index int, handler core.Handler) core.Handler {
+	if index == len(c.interceptors) {
+		return handler
+	}
+	return func(ctx context.Context, inv *core.Invocation) error {
+		return c.interceptors[index](ctx, inv, c.nextHandler(index+1, handler))
+	}
+}
+
+// 拦截器替换了query时, 将替换后的query的状态同步到原始的query
+func syncQueries(origins, queries []core.IQuery) {
+	if len(origins) != len(queries) {
+		panic(errors.New("interceptor must not change the number of queries"))
+	}
+	for i, q := range queries {
+		if origins[i] != q {
+			origins[i].SetError(q.Err())
+			setStale(origins[i], isStale(q))
+		}
+	}
+}
@@ -10,6 +10,7 @@ package zcache
 
 import (
 	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/envelope"
 	"github.com/zlyuancn/zcache/errs"
 	"github.com/zlyuancn/zcache/loader"
 	"github.com/zlyuancn/zcache/query"
@@ -18,8 +19,24 @@ import (
 var (
 	// 创建一个加载器
 	NewLoader = loader.NewLoader
+	// 创建一个批量加载器
+	NewBatchLoader = loader.NewBatchLoader
+	// 创建一个支持上下文的加载器
+	NewContextLoader = loader.NewContextLoader
+	// 创建一个支持上下文的批量加载器
+	NewContextBatchLoader = loader.NewContextBatchLoader
 	// 设置加载器的数据过期时间
 	WithLoaderExpire = loader.WithExpire
+	// 设置加载器软过期后可以提供旧数据的时间窗口
+	WithLoaderStaleWindow = loader.WithStaleWindow
+	// 设置加载器的过期数据保留时间, 加载失败时会返回过期数据
+	WithLoaderGracePeriod = loader.WithGracePeriod
+	// 设置批量加载器每批的最大数量
+	WithLoaderBatchSize = loader.WithBatchSize
+	// 设置加载器的数据不存在标记的过期时间
+	WithLoaderNotFoundExpire = loader.WithNotFoundExpire
+	// 设置加载器的提前刷新系数
+	WithLoaderEarlyRefresh = loader.WithEarlyRefresh
 )
 
 var (
@@ -36,12 +53,18 @@ var (
 	WithQueryArgs = query.WithArgs
 	// 设置查询元数据
 	WithQueryMeta = query.WithMeta
+	// 获取参数的文本, 结果和使用这个参数创建的query的ArgsText一致, 可以作为过滤器的key
+	ArgsText = query.ArgsText
 	// 设置查询加载器, 无数据时优先使用这个加载器
 	WithQueryLoader = query.WithLoader
 	// 设置查询加载函数, 效果等同于设置查询加载器
 	WithQueryLoaderFn = func(fn loader.LoaderFn, opts ...loader.Option) query.Option {
 		return query.WithLoader(loader.NewLoader(fn, opts...))
 	}
+	// 设置查询的支持上下文的加载函数, 效果等同于设置查询加载器
+	WithQueryContextLoaderFn = func(fn loader.ContextLoaderFn, opts ...loader.Option) query.Option {
+		return query.WithLoader(loader.NewContextLoader(fn, opts...))
+	}
 )
 
 var (
@@ -49,6 +72,8 @@ var (
 	LoaderNotFound = errs.LoaderNotFound
 	// 数据为nil
 	DataIsNil = errs.DataIsNil
+	// 数据不存在, 加载器返回这个错误时会缓存一个数据不存在标记
+	NotFound = errs.NotFound
 )
 
 // 错误列表
@@ -58,6 +83,18 @@ type Errors = errs.Errors
 var DecodeErrors = errs.DecodeErrors
 
 type (
-	ILoader = core.ILoader
-	IQuery  = core.IQuery
+	ILoader      = core.ILoader
+	IBatchLoader = core.IBatchLoader
+	IQuery       = core.IQuery
+
+	IContextLoader      = core.IContextLoader
+	IContextBatchLoader = core.IContextBatchLoader
+
+	Invocation  = core.Invocation
+	Interceptor = core.Interceptor
+
+	IFilter      = core.IFilter
+	FilterSeedFn = core.FilterSeedFn
+
+	Envelope = envelope.Envelope
 )
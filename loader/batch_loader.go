@@ -0,0 +1,71 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package loader
+
+import (
+	"context"
+	"errors"
+
+	"github.com/zlyuancn/zcache/core"
+)
+
+type BatchLoaderFn = func(queries []core.IQuery) (map[int]interface{}, error)
+type ContextBatchLoaderFn = func(ctx context.Context, queries []core.IQuery) (map[int]interface{}, error)
+
+var _ core.IContextBatchLoader = (*BatchLoader)(nil)
+
+// 批量加载器
+type BatchLoader struct {
+	*Loader
+	batchFn ContextBatchLoaderFn // 批量加载函数
+}
+
+// 创建一个批量加载器, 单条加载数据时也会调用批量加载函数
+func NewBatchLoader(fn BatchLoaderFn, opts ...Option) core.IBatchLoader {
+	if fn == nil {
+		panic(errors.New("load func of batch loader is empty"))
+	}
+	return NewContextBatchLoader(func(_ context.Context, queries []core.IQuery) (map[int]interface{}, error) {
+		return fn(queries)
+	}, opts...)
+}
+
+// 创建一个支持上下文的批量加载器, 加载函数会收到调用者的上下文
+func NewContextBatchLoader(fn ContextBatchLoaderFn, opts ...Option) core.IContextBatchLoader {
+	if fn == nil {
+		panic(errors.New("load func of batch loader is empty"))
+	}
+
+	l := &BatchLoader{batchFn: fn}
+	l.Loader = NewContextLoader(l.loadOne, opts...).(*Loader)
+	return l
+}
+
+func (l *BatchLoader) loadOne(ctx context.Context, query core.IQuery) (interface{}, error) {
+	result, err := l.batchFn(ctx, []core.IQuery{query})
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := result[0].(error); ok { // 和批量加载一样, 值为错误表示这条数据加载失败或不存在
+		return nil, e
+	}
+	return result[0], nil
+}
+
+func (l *BatchLoader) LoadMany(queries []core.IQuery) (map[int]interface{}, error) {
+	return l.LoadManyWithContext(context.Background(), queries)
+}
+
+func (l *BatchLoader) LoadManyWithContext(ctx context.Context, queries []core.IQuery) (map[int]interface{}, error) {
+	return l.batchFn(ctx, queries)
+}
+
+func (l *BatchLoader) BatchSize() int {
+	return l.batchSize
+}
@@ -9,6 +9,7 @@
 package loader
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 	"time"
@@ -17,16 +18,36 @@ import (
 )
 
 type LoaderFn = func(query core.IQuery) (interface{}, error)
+type ContextLoaderFn = func(ctx context.Context, query core.IQuery) (interface{}, error)
 
-var _ core.ILoader = (*Loader)(nil)
+var _ core.IContextLoader = (*Loader)(nil)
+var _ core.IStaleLoader = (*Loader)(nil)
+var _ core.IGraceLoader = (*Loader)(nil)
+var _ core.INotFoundLoader = (*Loader)(nil)
+var _ core.IEarlyRefreshLoader = (*Loader)(nil)
 
 type Loader struct {
-	fn                LoaderFn      // 加载函数
-	expire, maxExpire time.Duration // 有效时间
+	fn                ContextLoaderFn // 加载函数
+	expire, maxExpire time.Duration   // 有效时间
+	staleWindow       time.Duration   // 软过期后可以提供旧数据的时间窗口
+	gracePeriod       time.Duration   // 过期数据的保留时间, 加载失败时使用
+	batchSize         int             // 批量加载时每批的最大数量
+	notFoundExpire    time.Duration   // 数据不存在标记的过期时间
+	earlyRefreshBeta  float64         // 提前刷新的系数
 }
 
 // 创建一个加载器
 func NewLoader(fn LoaderFn, opts ...Option) core.ILoader {
+	if fn == nil {
+		panic(errors.New("load func of loader is empty"))
+	}
+	return NewContextLoader(func(_ context.Context, query core.IQuery) (interface{}, error) {
+		return fn(query)
+	}, opts...)
+}
+
+// 创建一个支持上下文的加载器, 加载函数会收到调用者的上下文
+func NewContextLoader(fn ContextLoaderFn, opts ...Option) core.IContextLoader {
 	if fn == nil {
 		panic(errors.New("load func of loader is empty"))
 	}
@@ -42,7 +63,11 @@ func NewLoader(fn LoaderFn, opts ...Option) core.ILoader {
 }
 
 func (l *Loader) Load(query core.IQuery) (interface{}, error) {
-	result, err := l.fn(query)
+	return l.LoadWithContext(context.Background(), query)
+}
+
+func (l *Loader) LoadWithContext(ctx context.Context, query core.IQuery) (interface{}, error) {
+	result, err := l.fn(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -55,3 +80,19 @@ func (l *Loader) Expire() (ex time.Duration) {
 	}
 	return l.expire
 }
+
+func (l *Loader) StaleWindow() time.Duration {
+	return l.staleWindow
+}
+
+func (l *Loader) GracePeriod() time.Duration {
+	return l.gracePeriod
+}
+
+func (l *Loader) NotFoundExpire() time.Duration {
+	return l.notFoundExpire
+}
+
+func (l *Loader) EarlyRefreshBeta() float64 {
+	return l.earlyRefreshBeta
+}
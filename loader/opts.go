@@ -27,3 +27,60 @@ func WithExpire(expire time.Duration, maxExpire ...time.Duration) Option {
 		}
 	}
 }
+
+// 设置软过期后可以提供旧数据的时间窗口
+//
+// 数据在过期时间后进入软过期, 在 staleWindow 时间内读取数据会立即返回旧数据, 同时在后台使用加载器刷新数据.
+// 超过这个时间窗口后数据才真正过期, 读取时会阻塞等待加载器加载数据.
+// 如果数据永不过期, 这个设置无效.
+func WithStaleWindow(staleWindow time.Duration) Option {
+	return func(l *Loader) {
+		if staleWindow < 0 {
+			staleWindow = 0
+		}
+		l.staleWindow = staleWindow
+	}
+}
+
+// 设置过期数据的保留时间, 优先级高于全局设置
+//
+// 数据过期后会在缓存中继续保留 gracePeriod 时间, 在这段时间内如果加载器返回错误或panic, 会返回过期的数据,
+// 同时 query 会被标记为过期数据. 如果数据永不过期, 这个设置无效.
+func WithGracePeriod(gracePeriod time.Duration) Option {
+	return func(l *Loader) {
+		if gracePeriod < 0 {
+			gracePeriod = 0
+		}
+		l.gracePeriod = gracePeriod
+	}
+}
+
+// 设置批量加载时每批的最大数量, 只对批量加载器有效
+//
+// 如果 n <= 0 (默认), 则不限制
+func WithBatchSize(n int) Option {
+	return func(l *Loader) {
+		if n < 0 {
+			n = 0
+		}
+		l.batchSize = n
+	}
+}
+
+// 设置加载器返回 errs.NotFound 时数据不存在标记的过期时间
+//
+// 如果 expire > 0 使用这个过期时间, expire = 0 (默认) 使用全局设置, expire < 0 表示不缓存数据不存在标记
+func WithNotFoundExpire(expire time.Duration) Option {
+	return func(l *Loader) {
+		l.notFoundExpire = expire
+	}
+}
+
+// 设置提前刷新的系数, 优先级高于全局设置
+//
+// 如果 beta > 0 使用这个系数, beta = 0 (默认) 使用全局设置, beta < 0 表示不启用. 如果数据永不过期, 这个设置无效.
+func WithEarlyRefresh(beta float64) Option {
+	return func(l *Loader) {
+		l.earlyRefreshBeta = beta
+	}
+}
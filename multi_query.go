@@ -31,25 +31,57 @@ func (c *Cache) MQueryWithContext(ctx context.Context, bucket string, a interfac
 	for i, qc := range queryConfigs {
 		queries[i] = NewQuery(bucket, qc)
 	}
-	return c.doWithContext(ctx, func() error {
-		err := c.mQuery(queries, a)
-		if err == nil {
-			return nil
-		}
-
+	return c.doWithContext(ctx, func(ctx context.Context) error {
+		err := c.mQuery(ctx, queries, a)
 		for i, qc := range queryConfigs {
 			qc.setError(queries[i].Err())
+			qc.setStale(isStale(queries[i]))
 		}
 		return err
 	})
 }
 
-func (c *Cache) mQuery(queries []core.IQuery, a interface{}) error {
-	realQueries := queries
-	if len(realQueries) == 0 {
+func (c *Cache) mQuery(ctx context.Context, queries []core.IQuery, a interface{}) error {
+	if len(queries) == 0 {
 		return nil
 	}
 
+	for _, q := range queries { // query可能被重复使用, 清除上次获取的状态
+		setStale(q, false)
+	}
+
+	var handlerErr error
+	inv := &core.Invocation{Op: core.OpMQuery, Queries: append([]core.IQuery(nil), queries...)}
+	err := c.intercept(ctx, inv, func(ctx context.Context, inv *core.Invocation) error {
+		inv.Data = c.mGetBytes(ctx, inv.Queries)
+		es := make([]error, len(inv.Queries))
+		for i, q := range inv.Queries {
+			es[i] = q.Err()
+		}
+		handlerErr = errs.NewErrors(es...).Err()
+		return handlerErr
+	})
+	syncQueries(queries, inv.Queries)
+
+	// 拦截器没有执行获取或返回了自己的错误时, 所有query都使用拦截器返回的错误
+	if (err != nil && err != handlerErr) || len(inv.Data) != len(queries) {
+		if err == nil {
+			err = errors.New("interceptor returned no data")
+		}
+		for _, q := range queries {
+			q.SetError(err)
+		}
+		return err
+	}
+	return c.writeBuffsTo(ctx, queries, inv.Data, a)
+}
+
+// 批量获取编码后的数据, 缓存未命中的数据会从加载器获取, 每条数据的错误会设置到query中
+//
+// 返回数据的数量和顺序和请求一致
+func (c *Cache) mGetBytes(ctx context.Context, queries []core.IQuery) [][]byte {
+	realQueries := queries
+
 	// 过滤重复的query
 	queryMap := make(map[uint64]core.IQuery, len(realQueries))
 	for _, q := range realQueries {
@@ -67,41 +99,96 @@ func (c *Cache) mQuery(queries []core.IQuery, a interface{}) error {
 		}
 	}
 
+	// 检查过滤器
+	if checked := c.filterQueries(ctx, realQueries); len(checked) != len(realQueries) {
+		isFilter, realQueries = true, checked
+	}
+	if len(realQueries) == 0 {
+		for _, q := range queries {
+			q.SetError(errs.NotFound)
+		}
+		return make([][]byte, len(queries))
+	}
+
 	// 批量从缓存获取数据
-	buffs, cacheErrs := c.cache.MGet(realQueries...)
+	mgetCtx, span := c.startSpan(ctx, core.SpanCacheMGet, nil)
+	span.SetAttribute(core.AttrBucket, realQueries[0].Bucket())
+	span.SetAttribute(core.AttrCount, len(realQueries))
+	buffs, cacheErrs := c.mGetByCacheDB(mgetCtx, realQueries)
 	if len(buffs) != len(realQueries) || len(cacheErrs) != len(realQueries) {
+		span.End()
 		panic("cached result is inconsistent with the number of requests")
 	}
 
-	// 遍历检查是否存在错误, 补充未命中的数据
+	// 遍历检查是否存在错误, 收集未命中的数据
+	var hits int
+	var spanErr error
+	var missIndexes []int
+	stales := make(map[int][]byte) // 已经过期但还在保留时间内的数据
 	for i, cacheErr := range cacheErrs {
+		q := realQueries[i]
 		if cacheErr == nil {
-			continue
+			var expired bool
+			buffs[i], expired, cacheErr = c.unpack(q, buffs[i])
+			if cacheErr == nil && !expired {
+				c.stats.Hit(q.Bucket())
+				hits++
+				continue
+			}
+			if cacheErr == errs.NotFound { // 数据不存在标记
+				c.stats.Hit(q.Bucket())
+				hits++
+				q.SetError(cacheErr)
+				continue
+			}
+			if expired {
+				stales[i], cacheErr = buffs[i], errs.CacheMiss
+			}
+			buffs[i] = nil
 		}
 
-		q := realQueries[i]
 		if cacheErr != errs.CacheMiss { // 非缓存未命中错误
-			if c.directReturnOnCacheFault { // 直接报告错误(不从加载器获取数据了)
+			c.stats.CacheError(q.Bucket(), cacheErr)
+			spanErr = cacheErr
+			if c.isDirectReturnOnCacheFault(q.Bucket()) { // 直接报告错误(不从加载器获取数据了)
 				q.SetError(cacheErr)
 				continue
 			}
 			cacheErr = fmt.Errorf("load from cache error, The data will be fetched from the loader. query: %s, args: %s, err: %s", q.Bucket(), q.ArgsText(), cacheErr)
 			c.log.Error(cacheErr)
 		}
+		c.stats.Miss(q.Bucket())
+		missIndexes = append(missIndexes, i)
+	}
+	span.SetAttribute(core.AttrHits, hits)
+	endSpan(span, spanErr)
 
-		// 从加载器获取数据
-		bs, err := c.sf.Do(q, c.load)
-		if err != nil {
-			q.SetError(err)
-			continue
+	// 从加载器获取数据
+	if len(missIndexes) > 0 {
+		missQueries := make([]core.IQuery, len(missIndexes))
+		for i, index := range missIndexes {
+			missQueries[i] = realQueries[index]
 		}
 
-		buffs[i] = bs
+		loadBuffs, loadErrs := c.loadMisses(ctx, missQueries)
+		for i, index := range missIndexes {
+			q, err := missQueries[i], loadErrs[i]
+			if err == nil {
+				buffs[index] = loadBuffs[i]
+				continue
+			}
+
+			if stale, ok := stales[index]; ok && c.useStale(q, err) {
+				buffs[index] = stale
+				continue
+			}
+			q.SetError(err)
+		}
 	}
 
 	// 如果没有进行过滤, 顺序和数量是不变的
 	if !isFilter {
-		return c.writeBuffsTo(queries, buffs, a)
+		return buffs
 	}
 
 	// 分发
@@ -111,16 +198,44 @@ func (c *Cache) mQuery(queries []core.IQuery, a interface{}) error {
 	}
 	realBuffs := make([][]byte, len(queries))
 	for i, q := range queries {
-		index := idMap[q.GlobalId()]
+		index, ok := idMap[q.GlobalId()]
+		if !ok { // 被过滤器判断为不存在的数据
+			q.SetError(errs.NotFound)
+			continue
+		}
 		realBuffs[i] = buffs[index]
 		q.SetError(realQueries[index].Err()) // 如果有重复的 query 出错, 为重复的那个query设置err
+		setStale(q, isStale(realQueries[index]))
 	}
+	return realBuffs
+}
 
-	return c.writeBuffsTo(queries, realBuffs, a)
+// 按bucket使用的缓存数据库分组批量获取, 返回数据和错误的顺序和请求一致
+func (c *Cache) mGetByCacheDB(ctx context.Context, queries []core.IQuery) ([][]byte, []error) {
+	dbs, groups := c.groupByCacheDB(len(queries), func(i int) string { return queries[i].Bucket() })
+	if len(dbs) == 1 {
+		return dbs[0].MGetWithContext(ctx, queries...)
+	}
+
+	buffs, es := make([][]byte, len(queries)), make([]error, len(queries))
+	for g, db := range dbs {
+		groupQueries := make([]core.IQuery, len(groups[g]))
+		for i, index := range groups[g] {
+			groupQueries[i] = queries[index]
+		}
+		groupBuffs, groupErrs := db.MGetWithContext(ctx, groupQueries...)
+		if len(groupBuffs) != len(groupQueries) || len(groupErrs) != len(groupQueries) {
+			return nil, nil
+		}
+		for i, index := range groups[g] {
+			buffs[index], es[index] = groupBuffs[i], groupErrs[i]
+		}
+	}
+	return buffs, es
 }
 
 // 将批量获取的数据写入a中
-func (c *Cache) writeBuffsTo(queries []core.IQuery, buffs [][]byte, a interface{}) error {
+func (c *Cache) writeBuffsTo(ctx context.Context, queries []core.IQuery, buffs [][]byte, a interface{}) error {
 	// 检查输出
 	rt := reflect.TypeOf(a)
 	if rt.Kind() != reflect.Ptr {
@@ -133,16 +248,16 @@ func (c *Cache) writeBuffsTo(queries []core.IQuery, buffs [][]byte, a interface{
 	case reflect.Invalid:
 		panic(errors.New("A is invalid, it may not be initialized"))
 	case reflect.Slice:
-		return c.writeBuffsToSlice(queries, buffs, rt, rv)
+		return c.writeBuffsToSlice(ctx, queries, buffs, rt, rv)
 	case reflect.Array:
-		return c.writeBuffsToArray(queries, buffs, rt, rv)
+		return c.writeBuffsToArray(ctx, queries, buffs, rt, rv)
 	default:
 		panic(errors.New("A must be a slice pointer of length 0 or an array pointer of length equal to the number of requests"))
 	}
 }
 
 // 将批量获取的数据写入切片中
-func (c *Cache) writeBuffsToSlice(queries []core.IQuery, buffs [][]byte, sliceType reflect.Type, sliceValue reflect.Value) error {
+func (c *Cache) writeBuffsToSlice(ctx context.Context, queries []core.IQuery, buffs [][]byte, sliceType reflect.Type, sliceValue reflect.Value) error {
 	if sliceValue.Kind() == reflect.Invalid {
 		panic(errors.New("A is invalid"))
 	}
@@ -162,7 +277,7 @@ func (c *Cache) writeBuffsToSlice(queries []core.IQuery, buffs [][]byte, sliceTy
 		child := reflect.New(itemType) // 创建一个相同类型的指针
 		e := queries[i].Err()
 		if e == nil {
-			e = c.unmarshal(bs, child.Interface())
+			e = c.unmarshal(ctx, queries[i], bs, child.Interface())
 		}
 		queries[i].SetError(e)
 		err.AddErr(e)
@@ -179,7 +294,7 @@ func (c *Cache) writeBuffsToSlice(queries []core.IQuery, buffs [][]byte, sliceTy
 }
 
 // 将批量获取的数据写入数组中
-func (c *Cache) writeBuffsToArray(queries []core.IQuery, buffs [][]byte, arrayType reflect.Type, arrayValue reflect.Value) error {
+func (c *Cache) writeBuffsToArray(ctx context.Context, queries []core.IQuery, buffs [][]byte, arrayType reflect.Type, arrayValue reflect.Value) error {
 	if arrayValue.Kind() == reflect.Invalid {
 		panic(errors.New("A is invalid"))
 	}
@@ -198,7 +313,7 @@ func (c *Cache) writeBuffsToArray(queries []core.IQuery, buffs [][]byte, arrayTy
 		child := reflect.New(itemType) // 创建一个相同类型的指针
 		e := queries[i].Err()
 		if e == nil {
-			e = c.unmarshal(bs, child.Interface())
+			e = c.unmarshal(ctx, queries[i], bs, child.Interface())
 		}
 		queries[i].SetError(e)
 		err.AddErr(e)
@@ -0,0 +1,150 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package zcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/errs"
+)
+
+// 批量保存数据到缓存, 同MSaveWithContext
+func (c *Cache) MSave(bucket string, a interface{}, ex time.Duration, queryConfigs ...*QueryConfig) error {
+	return c.MSaveWithContext(nil, bucket, a, ex, queryConfigs...)
+}
+
+// 批量保存数据到缓存
+//
+// a 可以是切片或数组, 长度必须和 queryConfigs 的数量一致, 每个值会保存到对应的 query 中.
+// a 也可以是map, 此时map的key会作为 query 的参数, 不需要传入 queryConfigs.
+//
+// ex < 0 表示永不过期, ex = 0 表示使用默认过期时间.
+// 返回的错误可以通过 DecodeErrors 解包为 *Errors 获取每条数据的错误
+func (c *Cache) MSaveWithContext(ctx context.Context, bucket string, a interface{}, ex time.Duration, queryConfigs ...*QueryConfig) error {
+	queries, values := makeSaveItems(bucket, a, queryConfigs)
+	if len(queries) == 0 {
+		return nil
+	}
+
+	return c.doWithContext(ctx, func(ctx context.Context) error {
+		es := c.mSet(ctx, queries, values, ex)
+		for i, qc := range queryConfigs {
+			qc.setError(es[i])
+		}
+		return errs.NewErrors(es...).Err()
+	})
+}
+
+// 根据要保存的数据构建query和值
+func makeSaveItems(bucket string, a interface{}, queryConfigs []*QueryConfig) ([]core.IQuery, []interface{}) {
+	rv := reflect.ValueOf(a)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Len() != len(queryConfigs) {
+			panic(errors.New("length of A is not equal to the number of queryConfigs"))
+		}
+		queries := make([]core.IQuery, rv.Len())
+		values := make([]interface{}, rv.Len())
+		for i, qc := range queryConfigs {
+			queries[i] = NewQuery(bucket, qc)
+			values[i] = rv.Index(i).Interface()
+		}
+		return queries, values
+	case reflect.Map:
+		if len(queryConfigs) != 0 {
+			panic(errors.New("queryConfigs must be empty when A is a map"))
+		}
+		queries := make([]core.IQuery, 0, rv.Len())
+		values := make([]interface{}, 0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			queries = append(queries, NewQuery(bucket, NewQueryConfig().Args(iter.Key().Interface())))
+			values = append(values, iter.Value().Interface())
+		}
+		return queries, values
+	default:
+		panic(errors.New("A must be a slice, array or map"))
+	}
+}
+
+// 批量写入数据到缓存, 返回错误的数量和请求数量一致
+func (c *Cache) mSet(ctx context.Context, queries []core.IQuery, values []interface{}, ex time.Duration) []error {
+	es := make([]error, len(queries))
+	okQueries := make([]core.IQuery, 0, len(queries))
+	buffs := make([][]byte, 0, len(queries))
+	indexes := make([]int, 0, len(queries))
+	for i, q := range queries {
+		bs, err := c.marshal(ctx, q, values[i])
+		if err != nil {
+			es[i] = err
+			q.SetError(err)
+			continue
+		}
+		okQueries = append(okQueries, q)
+		buffs = append(buffs, bs)
+		indexes = append(indexes, i)
+	}
+	if len(okQueries) == 0 {
+		return es
+	}
+
+	var cacheErrs []error
+	var handlerErr error
+	inv := &core.Invocation{Op: core.OpSet, Queries: okQueries, Data: buffs}
+	err := c.intercept(ctx, inv, func(ctx context.Context, inv *core.Invocation) error {
+		cacheErrs = c.mSetBytes(ctx, inv.Queries, inv.Data, ex)
+		handlerErr = errs.NewErrors(cacheErrs...).Err()
+		return handlerErr
+	})
+
+	// 拦截器没有执行写入或返回了自己的错误时, 所有query都使用拦截器返回的错误
+	useErr := (err != nil && err != handlerErr) || len(cacheErrs) != len(indexes)
+	for i, index := range indexes {
+		e := err
+		if !useErr {
+			e = cacheErrs[i]
+		}
+		if e != nil {
+			es[index] = e
+			queries[index].SetError(e)
+		}
+	}
+	return es
+}
+
+// 批量将编码后的数据写入缓存, 返回错误的数量和请求数量一致
+func (c *Cache) mSetBytes(ctx context.Context, queries []core.IQuery, buffs [][]byte, ex time.Duration) []error {
+	items := make([]core.SetItem, len(queries))
+	for i, q := range queries {
+		data, expire := c.pack(q, c.findLoader(q), buffs[i], c.makeExpire(q, ex), 0)
+		items[i] = core.SetItem{Query: q, Data: data, Expire: expire}
+	}
+
+	es := c.cacheMSet(ctx, items)
+	written := make([]core.IQuery, 0, len(queries))
+	for i, err := range es {
+		if err != nil {
+			c.stats.CacheError(queries[i].Bucket(), err)
+			es[i] = fmt.Errorf("write to cache error: %s", err)
+			continue
+		}
+		written = append(written, queries[i])
+	}
+	c.addToFilter(ctx, written...)
+	return es
+}
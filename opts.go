@@ -11,11 +11,15 @@ package zcache
 import (
 	"time"
 
+	"github.com/zlyuancn/zcache/cachedb"
 	"github.com/zlyuancn/zcache/cachedb/no-cache"
 	"github.com/zlyuancn/zcache/codec"
 	"github.com/zlyuancn/zcache/core"
 	"github.com/zlyuancn/zcache/logger"
+	"github.com/zlyuancn/zcache/single_flight"
 	no_sf "github.com/zlyuancn/zcache/single_flight/no-sf"
+	"github.com/zlyuancn/zcache/stats"
+	"github.com/zlyuancn/zcache/tracer"
 )
 
 type Option func(c *Cache)
@@ -26,7 +30,7 @@ func WithCacheDB(cacheDB core.ICacheDB) Option {
 		if cacheDB == nil {
 			cacheDB = no_cache.NoCache()
 		}
-		c.cache = cacheDB
+		c.cache = cachedb.ToContextCacheDB(cacheDB)
 	}
 }
 
@@ -43,6 +47,71 @@ func WithDefaultExpire(expire time.Duration, maxExpire ...time.Duration) Option
 	}
 }
 
+// 设置全局的过期数据保留时间, 加载器设置的保留时间优先级更高
+//
+// 数据过期后会在缓存中继续保留 gracePeriod 时间, 在这段时间内如果加载器返回错误或panic, 会返回过期的数据,
+// 同时 query 会被标记为过期数据. gracePeriod <= 0 (默认) 表示不启用.
+func WithGracePeriod(gracePeriod time.Duration) Option {
+	return func(c *Cache) {
+		if gracePeriod < 0 {
+			gracePeriod = 0
+		}
+		c.gracePeriod = gracePeriod
+	}
+}
+
+// 设置全局的数据不存在标记的过期时间, 加载器设置的过期时间优先级更高
+//
+// 加载器返回 errs.NotFound 时会在缓存中写入一个数据不存在标记, 在标记过期前的查询会直接返回 errs.NotFound.
+// 默认为1分钟, expire <= 0 表示不缓存数据不存在标记.
+func WithNotFoundExpire(expire time.Duration) Option {
+	return func(c *Cache) {
+		c.notFoundExpire = expire
+	}
+}
+
+// 为bucket设置存在性过滤器, 同 Cache.RegisterFilter
+func WithFilter(bucket string, f core.IFilter) Option {
+	return func(c *Cache) {
+		c.RegisterFilter(bucket, f)
+	}
+}
+
+// 设置bucket的配置, 同 Cache.RegisterBucket
+func WithBucket(bucket string, conf BucketConfig) Option {
+	return func(c *Cache) {
+		c.RegisterBucket(bucket, conf)
+	}
+}
+
+// 设置全局的提前刷新系数, 加载器设置的系数优先级更高
+//
+// 使用 XFetch 算法, 数据快要过期时每次读取都有一定概率在后台刷新数据, 避免热点数据过期时所有实例同时加载.
+// 刷新概率由剩余有效时间和上一次的加载耗时决定, beta 越大越早开始刷新, 一般设为1.
+// beta <= 0 (默认) 表示不启用. 如果数据永不过期, 这个设置无效.
+func WithEarlyRefresh(beta float64) Option {
+	return func(c *Cache) {
+		c.earlyRefreshBeta = beta
+	}
+}
+
+// 写入缓存的数据总是打包为信封格式
+//
+// 信封的头部记录了写入时间, 编解码器id, 加载耗时, 过期时间等元数据, 可以通过 Cache.GetEnvelope 查看.
+// 不管是否启用, 都可以读取信封格式和非信封格式的数据, 所以可以随时开启或关闭.
+func WithEnvelope(b ...bool) Option {
+	return func(c *Cache) {
+		c.envelope = len(b) == 0 || b[0]
+	}
+}
+
+// 设置返回过期数据时的回调, 可以用于统计加载失败时使用过期数据的次数
+func WithOnServeStale(fn func(query core.IQuery, loadErr error)) Option {
+	return func(c *Cache) {
+		c.onServeStale = fn
+	}
+}
+
 // 在缓存故障时直接返回缓存错误(默认)
 func WithDirectReturnOnCacheFault(b ...bool) Option {
 	return func(c *Cache) {
@@ -57,23 +126,37 @@ func WithPanicOnLoaderExists(err ...bool) Option {
 	}
 }
 
-// 设置编码器
+// 设置编码器, 有id的编码器会被记录到这个缓存的编解码器表中, 不会影响全局注册表
+//
+// 数据被打包为信封格式时(启用了数据信封, 过期数据保留, 软过期或提前刷新), 数据中会记录编码器的id, 读取时使用对应的编解码器解码,
+// 所以修改编码器后仍然可以读取旧数据. 旧的编码器需要在这个缓存中使用过或者通过 codec.Register 注册.
+// 其它数据不会记录编码器id, 修改编码器前需要开启 WithEnvelope, 或者通过 WithFallbackCodecs 设置旧的编码器
 func WithCodec(c core.ICodec) Option {
 	return func(cache *Cache) {
 		if c == nil {
 			c = codec.DefaultCodec
 		}
+		cache.registerCodec(c)
 		cache.codec = c
 	}
 }
 
+// 设置备用编解码器, 没有记录编解码器id的数据使用当前编码器解码失败时, 会依次尝试使用备用编解码器解码
+//
+// 可以在修改编码器时设置为旧的编码器, 用于读取没有启用数据信封时写入的旧数据. 使用加密编解码器的bucket不会使用备用编解码器
+func WithFallbackCodecs(codecs ...core.ICodec) Option {
+	return func(c *Cache) {
+		c.fallbackCodecs = codecs
+	}
+}
+
 // 设置单跑模块
 func WithSingleFlight(sf core.ISingleFlight) Option {
 	return func(c *Cache) {
 		if sf == nil {
 			sf = no_sf.NoSingleFlight()
 		}
-		c.sf = sf
+		c.sf = single_flight.ToContextSingleFlight(sf)
 	}
 }
 
@@ -86,3 +169,41 @@ func WithLogger(log core.ILogger) Option {
 		m.log = log
 	}
 }
+
+// 设置统计收集器, 可以使用 memory_stats.NewMemoryStats 收集命中率, 加载耗时等数据
+func WithStats(s core.IStats) Option {
+	return func(c *Cache) {
+		if s == nil {
+			s = stats.NoStats()
+		}
+		c.stats = s
+	}
+}
+
+// 添加拦截器, 拦截器会包装 Get, MQuery, Set, Del, DelBucket 和 Load 操作, 先添加的拦截器在外层
+func WithInterceptors(interceptors ...core.Interceptor) Option {
+	return func(c *Cache) {
+		for _, interceptor := range interceptors {
+			if interceptor != nil {
+				c.interceptors = append(c.interceptors, interceptor)
+			}
+		}
+	}
+}
+
+// 设置链路追踪, 可以使用 otel_tracer.NewOtelTracer 接入 OpenTelemetry
+func WithTracer(t core.ITracer) Option {
+	return func(c *Cache) {
+		if t == nil {
+			t = tracer.NoTracer()
+		}
+		c.tracer = t
+	}
+}
+
+// 添加关闭缓存时调用的函数, 在缓存数据库关闭后调用
+func withCloser(fn func() error) Option {
+	return func(c *Cache) {
+		c.closers = append(c.closers, fn)
+	}
+}
@@ -0,0 +1,233 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package zcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/zlyuancn/zcache/codec"
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/envelope"
+	"github.com/zlyuancn/zcache/errs"
+	"github.com/zlyuancn/zcache/query"
+)
+
+// 获取query使用的加载器, 加载器不存在时返回nil
+func (c *Cache) findLoader(query core.IQuery) core.ILoader {
+	if l := query.Loader(); l != nil { // 查询加载器的优先级高于注册表的加载器
+		return l
+	}
+	return c.getLoader(query.Bucket())
+}
+
+// 获取加载器的软过期窗口
+func (c *Cache) makeStaleWindow(l core.ILoader) time.Duration {
+	if sl, ok := l.(core.IStaleLoader); ok {
+		return sl.StaleWindow()
+	}
+	return 0
+}
+
+// 获取过期数据的保留时间, 加载器的设置优先
+func (c *Cache) makeGracePeriod(l core.ILoader) time.Duration {
+	if gl, ok := l.(core.IGraceLoader); ok {
+		if gracePeriod := gl.GracePeriod(); gracePeriod > 0 {
+			return gracePeriod
+		}
+	}
+	return c.gracePeriod
+}
+
+// 获取提前刷新的系数, 加载器的设置优先, 返回值 <= 0 表示不启用
+func (c *Cache) makeEarlyRefreshBeta(l core.ILoader) float64 {
+	if el, ok := l.(core.IEarlyRefreshLoader); ok {
+		if beta := el.EarlyRefreshBeta(); beta != 0 {
+			return beta
+		}
+	}
+	return c.earlyRefreshBeta
+}
+
+// 获取数据不存在标记的过期时间, 加载器的设置优先, 返回值 <= 0 表示不缓存数据不存在标记
+func (c *Cache) makeNotFoundExpire(l core.ILoader) time.Duration {
+	if nl, ok := l.(core.INotFoundLoader); ok {
+		if expire := nl.NotFoundExpire(); expire != 0 {
+			return expire
+		}
+	}
+	return c.notFoundExpire
+}
+
+// 构建数据不存在标记, 返回写入缓存的数据和过期时间, 过期时间 <= 0 表示不需要写入缓存
+func (c *Cache) packNotFound(l core.ILoader) ([]byte, time.Duration) {
+	expire := c.makeNotFoundExpire(l)
+	if expire <= 0 {
+		return nil, expire
+	}
+	e := &envelope.Envelope{Flags: envelope.FlagNotFound}
+	if c.envelope {
+		e.CreatedAt = time.Now().UnixNano()
+	}
+	return envelope.Encode(e), expire
+}
+
+// 将编码后的数据打包为写入缓存的数据, 返回打包后的数据和实际写入缓存的过期时间
+//
+// loadDuration 为加载数据的耗时, 不是从加载器加载的数据为0. 启用数据信封时总是会打包为信封格式, 打包为信封格式时总是会记录编解码器id
+func (c *Cache) pack(query core.IQuery, l core.ILoader, bs []byte, expire, loadDuration time.Duration) ([]byte, time.Duration) {
+	var staleWindow, gracePeriod time.Duration
+	var earlyRefresh bool
+	if expire > 0 { // 永不过期的数据不需要这些设置
+		staleWindow, gracePeriod = c.makeStaleWindow(l), c.makeGracePeriod(l)
+		earlyRefresh = loadDuration > 0 && c.makeEarlyRefreshBeta(l) > 0
+	}
+	if !c.envelope && staleWindow <= 0 && gracePeriod <= 0 && !earlyRefresh {
+		return bs, expire
+	}
+
+	now := time.Now()
+	e := &envelope.Envelope{Data: bs, CodecId: codec.IdOf(c.bucketCodec(query.Bucket()))}
+	if c.envelope {
+		e.CreatedAt = now.UnixNano()
+		e.LoadDuration = int64(loadDuration)
+	}
+	if expire <= 0 {
+		return envelope.Encode(e), expire
+	}
+
+	// 数据在 expire 后软过期, 在 expire+staleWindow 后过期, 在 expire+staleWindow+gracePeriod 后从缓存中删除
+	if staleWindow > 0 {
+		e.SoftExpireAt = now.Add(expire).UnixNano()
+	}
+	if gracePeriod > 0 {
+		e.ExpireAt = now.Add(expire + staleWindow).UnixNano()
+	}
+	if c.envelope || earlyRefresh {
+		e.FreshUntil = now.Add(expire).UnixNano()
+		e.LoadDuration = int64(loadDuration)
+	}
+	return envelope.Encode(e), expire + staleWindow + gracePeriod
+}
+
+// 解包从缓存读取的数据, 数据已经软过期或需要提前刷新时会在后台刷新
+//
+// 如果数据已经过期但还在保留时间内, expired 为 true. 如果是数据不存在标记, 返回 errs.NotFound.
+// 如果数据不是由当前的编解码器编码的, 返回的数据会保留编解码器id, 解码时使用对应的编解码器
+func (c *Cache) unpack(query core.IQuery, bs []byte) (data []byte, expired bool, err error) {
+	e := envelope.Decode(bs)
+	if e.IsNotFound() {
+		return nil, false, errs.NotFound
+	}
+
+	data = e.Data
+	if e.CodecId != 0 && e.CodecId != codec.IdOf(c.bucketCodec(query.Bucket())) {
+		data = envelope.Encode(&envelope.Envelope{CodecId: e.CodecId, Data: e.Data})
+	}
+
+	now := time.Now().UnixNano()
+	if e.ExpireAt > 0 && now > e.ExpireAt {
+		return data, true, nil
+	}
+	if (e.SoftExpireAt > 0 && now > e.SoftExpireAt) || c.shouldEarlyRefresh(query, e, now) {
+		c.refresh(query)
+	}
+	return data, false, nil
+}
+
+// 获取缓存中数据的信封, 可以查看数据的元数据. 不是信封格式的数据只有 Data 字段
+//
+// 数据不存在时返回 errs.CacheMiss
+func (c *Cache) GetEnvelope(query core.IQuery) (*envelope.Envelope, error) {
+	return c.GetEnvelopeWithContext(nil, query)
+}
+
+// 获取缓存中数据的信封, 可以查看数据的元数据. 不是信封格式的数据只有 Data 字段
+//
+// 数据不存在时返回 errs.CacheMiss
+func (c *Cache) GetEnvelopeWithContext(ctx context.Context, query core.IQuery) (*envelope.Envelope, error) {
+	var e *envelope.Envelope
+	err := c.doWithContext(ctx, func(ctx context.Context) error {
+		bs, err := c.bucketCacheDB(query.Bucket()).GetWithContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		e = envelope.Decode(bs)
+		return nil
+	})
+	return e, err
+}
+
+// 使用 XFetch 算法检查是否需要提前刷新数据
+//
+// 当 now - loadDuration * beta * ln(rand()) >= freshUntil 时刷新, 越接近过期时间, 加载耗时越长, 刷新的概率越大
+func (c *Cache) shouldEarlyRefresh(query core.IQuery, e *envelope.Envelope, now int64) bool {
+	if e.FreshUntil <= 0 || e.LoadDuration <= 0 {
+		return false
+	}
+	beta := c.makeEarlyRefreshBeta(c.findLoader(query))
+	if beta <= 0 {
+		return false
+	}
+	gap := float64(e.LoadDuration) * beta * -math.Log(1-rand.Float64()) // rand.Float64() 可能为0, 使用 1-rand 避免 ln(0)
+	return float64(now)+gap >= float64(e.FreshUntil)
+}
+
+// 加载失败时检查是否可以使用过期数据, 可以使用时会将query标记为过期数据
+func (c *Cache) useStale(query core.IQuery, loadErr error) bool {
+	if loadErr == errs.LoaderNotFound || errors.Is(loadErr, errs.NotFound) {
+		return false
+	}
+
+	setStale(query, true)
+	c.log.Error(fmt.Errorf("load data error, the expired data will be returned. query: %s, args: %s, err: %s", query.Bucket(), query.ArgsText(), loadErr))
+	if c.onServeStale != nil {
+		c.onServeStale(query, loadErr)
+	}
+	return true
+}
+
+// 在后台刷新数据, 同一条数据同时只会有一个刷新任务
+//
+// 刷新任务不受调用者上下文的影响. 调用者可能在返回后重复使用query, 所以刷新任务使用query的副本
+func (c *Cache) refresh(q core.IQuery) {
+	id := q.GlobalId()
+	if _, loaded := c.refreshing.LoadOrStore(id, struct{}{}); loaded {
+		return
+	}
+	query := query.Clone(q)
+
+	go func() {
+		defer c.refreshing.Delete(id)
+
+		_, err := c.sfLoad(context.Background(), query)
+		if err != nil {
+			c.log.Error(fmt.Errorf("refresh data error. query: %s, args: %s, err: %s", query.Bucket(), query.ArgsText(), err))
+		}
+	}()
+}
+
+// 返回的数据是否为过期数据, query没有实现 core.IStaleQuery 时返回false
+func isStale(query core.IQuery) bool {
+	if q, ok := query.(core.IStaleQuery); ok {
+		return q.IsStale()
+	}
+	return false
+}
+
+// 设置是否为过期数据, query没有实现 core.IStaleQuery 时忽略
+func setStale(query core.IQuery, stale bool) {
+	if q, ok := query.(core.IStaleQuery); ok {
+		q.SetStale(stale)
+	}
+}
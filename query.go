@@ -10,10 +10,14 @@ package zcache
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/zlyuancn/zcache/core"
 	"github.com/zlyuancn/zcache/errs"
+	"github.com/zlyuancn/zcache/query"
 	"github.com/zlyuancn/zcache/wrap_call"
 )
 
@@ -32,34 +36,89 @@ func (c *Cache) Get(query core.IQuery, a interface{}) error {
 
 // 获取数据
 func (c *Cache) GetWithContext(ctx context.Context, query core.IQuery, a interface{}) error {
-	return c.doWithContext(ctx, func() error {
-		err := c.get(query, a)
+	return c.doWithContext(ctx, func(ctx context.Context) error {
+		err := c.get(ctx, query, a)
 		query.SetError(err)
 		return err
 	})
 }
-func (c *Cache) get(query core.IQuery, a interface{}) error {
+func (c *Cache) get(ctx context.Context, query core.IQuery, a interface{}) error {
+	setStale(query, false) // query可能被重复使用, 清除上次获取的状态
+	inv := &core.Invocation{Op: core.OpGet, Queries: []core.IQuery{query}}
+	err := c.intercept(ctx, inv, func(ctx context.Context, inv *core.Invocation) error {
+		bs, err := c.getBytes(ctx, inv.Queries[0])
+		inv.Data = [][]byte{bs}
+		return err
+	})
+	syncQueries([]core.IQuery{query}, inv.Queries)
+	if err != nil {
+		return err
+	}
+
+	var bs []byte
+	if len(inv.Data) > 0 {
+		bs = inv.Data[0]
+	}
+	return c.unmarshal(ctx, query, bs, a)
+}
+
+// 获取一条数据编码后的数据, 缓存未命中时从加载器获取
+func (c *Cache) getBytes(ctx context.Context, query core.IQuery) ([]byte, error) {
+	// 检查过滤器
+	if len(c.filterQueries(ctx, []core.IQuery{query})) == 0 {
+		return nil, errs.NotFound
+	}
+
 	// 从缓存获取数据
-	bs, cacheErr := c.cache.Get(query)
+	bs, expired, cacheErr := c.cacheGet(ctx, query)
+	var stale []byte // 已经过期但还在保留时间内的数据
+	var hasStale bool
+	if cacheErr == errs.NotFound { // 数据不存在标记
+		c.stats.Hit(query.Bucket())
+		return nil, cacheErr
+	}
 	if cacheErr == nil {
-		return c.unmarshal(bs, a)
+		if !expired {
+			c.stats.Hit(query.Bucket())
+			return bs, nil
+		}
+		stale, hasStale, cacheErr = bs, true, errs.CacheMiss
 	}
 	if cacheErr != errs.CacheMiss { // 非缓存未命中错误
-		if c.directReturnOnCacheFault { // 直接报告错误
+		c.stats.CacheError(query.Bucket(), cacheErr)
+		if c.isDirectReturnOnCacheFault(query.Bucket()) { // 直接报告错误
 			cacheErr = fmt.Errorf("load from cache error: %s", cacheErr)
-			return cacheErr
+			return nil, cacheErr
 		}
 		cacheErr = fmt.Errorf("load from cache error, The data will be fetched from the loader. query: %s, args: %s, err: %s", query.Bucket(), query.ArgsText(), cacheErr)
 		c.log.Error(cacheErr)
 	}
 
 	// 从加载器获取数据
-	bs, err := c.sf.Do(query, c.load)
+	c.stats.Miss(query.Bucket())
+	bs, err := c.sfLoad(ctx, query)
 	if err != nil {
-		return err
+		if hasStale && c.useStale(query, err) {
+			return stale, nil
+		}
+		return nil, err
 	}
+	return bs, nil
+}
 
-	return c.unmarshal(bs, a)
+// 从缓存获取一条数据并解包, 数据已经过期但还在保留时间内时 expired 为 true
+func (c *Cache) cacheGet(ctx context.Context, query core.IQuery) (bs []byte, expired bool, err error) {
+	ctx, span := c.startSpan(ctx, core.SpanCacheGet, query)
+	bs, err = c.bucketCacheDB(query.Bucket()).GetWithContext(ctx, query)
+	if err == nil {
+		bs, expired, err = c.unpack(query, bs)
+	}
+	span.SetAttribute(core.AttrHit, (err == nil && !expired) || err == errs.NotFound)
+	if err != errs.CacheMiss && err != errs.NotFound {
+		span.RecordError(err)
+	}
+	span.End()
+	return bs, expired, err
 }
 
 // 获取数据
@@ -70,45 +129,102 @@ func (c *Cache) Query(bucket string, a interface{}, queryConfig ...*QueryConfig)
 // 获取数据
 func (c *Cache) QueryWithContext(ctx context.Context, bucket string, a interface{}, queryConfig ...*QueryConfig) error {
 	query := NewQuery(bucket, queryConfig...)
-	return c.doWithContext(ctx, func() error {
-		err := c.get(query, a)
+	return c.doWithContext(ctx, func(ctx context.Context) error {
+		err := c.get(ctx, query, a)
 		query.SetError(err)
 		if len(queryConfig) > 0 {
 			queryConfig[0].setError(err)
+			queryConfig[0].setStale(isStale(query))
 		}
 		return err
 	})
 }
 
+// 通过单跑模块加载数据, 等待了其它调用者的加载结果时会记录到统计
+func (c *Cache) sfLoad(ctx context.Context, query core.IQuery) ([]byte, error) {
+	ctx, span := c.startSpan(ctx, core.SpanSingleFlight, query)
+	var called int32 // 调用者的上下文结束后单跑模块可能在后台继续加载, 所以使用原子操作和query的副本
+	bs, err := c.sf.DoWithContext(ctx, detachQuery(ctx, query), func(ctx context.Context, query core.IQuery) ([]byte, error) {
+		atomic.StoreInt32(&called, 1)
+		return c.load(ctx, query)
+	})
+	var shared int
+	if atomic.LoadInt32(&called) == 0 {
+		c.stats.SharedWait(query.Bucket())
+		shared = 1
+	}
+	span.SetAttribute(core.AttrShared, shared)
+	endSpan(span, err)
+	return bs, err
+}
+
+// ctx可能结束时返回query的副本, 单跑模块可能在调用者返回后继续使用它
+func detachQuery(ctx context.Context, q core.IQuery) core.IQuery {
+	if ctx.Done() == nil {
+		return q
+	}
+	return query.Clone(q)
+}
+
 // 加载数据并写入缓存
-func (c *Cache) load(query core.IQuery) (bs []byte, err error) {
+func (c *Cache) load(ctx context.Context, query core.IQuery) ([]byte, error) {
+	inv := &core.Invocation{Op: core.OpLoad, Queries: []core.IQuery{query}}
+	err := c.intercept(ctx, inv, func(ctx context.Context, inv *core.Invocation) error {
+		bs, err := c.loadOne(ctx, inv.Queries[0])
+		inv.Data = [][]byte{bs}
+		return err
+	})
+	syncQueries([]core.IQuery{query}, inv.Queries)
+	if err != nil || len(inv.Data) == 0 {
+		return nil, err
+	}
+	return inv.Data[0], nil
+}
+
+// 使用加载器加载一条数据并写入缓存
+func (c *Cache) loadOne(ctx context.Context, query core.IQuery) (bs []byte, err error) {
 	err = wrap_call.WrapCall(func() error {
 		// 获取加载器
-		l := query.Loader() // 查询加载器的优先级高于注册表的加载器
-		if l == nil {
-			l = c.getLoader(query.Bucket()) // 没有查询加载器时从注册表中获取加载器
-		}
+		l := c.findLoader(query)
 		if l == nil {
 			return errs.LoaderNotFound
 		}
 
 		// 加载数据
-		result, err := l.Load(query)
+		var result interface{}
+		loadCtx, span := c.startSpan(ctx, core.SpanLoad, query)
+		start := time.Now()
+		err := wrap_call.WrapCall(func() (err error) {
+			result, err = loadWithContext(loadCtx, l, query)
+			return err
+		})
+		notFound := errors.Is(err, errs.NotFound)
+		if notFound { // 数据不存在不是加载错误
+			err = nil
+		}
+		latency := time.Since(start)
+		c.stats.Load(query.Bucket(), latency, err)
+		endSpan(span, err)
 		if err != nil {
 			return fmt.Errorf("load data error from loader: %s", err)
 		}
+		if notFound {
+			return c.setNotFound(ctx, query, l)
+		}
 
 		// 编码
-		bs, err = c.marshal(result)
+		bs, err = c.marshal(ctx, query, result)
 		if err != nil {
 			return err
 		}
 
 		// 写入缓存
-		cacheErr := c.cache.Set(query, bs, c.makeExpire(nil, l.Expire()))
+		data, expire := c.pack(query, l, bs, c.makeExpire(query, l.Expire()), latency)
+		cacheErr := c.cacheSet(ctx, query, data, expire)
 		if cacheErr != nil {
+			c.stats.CacheError(query.Bucket(), cacheErr)
 			cacheErr = fmt.Errorf("write to cache error: %s", cacheErr)
-			if c.directReturnOnCacheFault {
+			if c.isDirectReturnOnCacheFault(query.Bucket()) {
 				return cacheErr
 			}
 			c.log.Error(cacheErr)
@@ -117,3 +233,22 @@ func (c *Cache) load(query core.IQuery) (bs []byte, err error) {
 	})
 	return bs, err
 }
+
+// 写入数据不存在标记, 返回 errs.NotFound 或者写入缓存的错误
+func (c *Cache) setNotFound(ctx context.Context, query core.IQuery, l core.ILoader) error {
+	data, expire := c.packNotFound(l)
+	if expire <= 0 {
+		return errs.NotFound
+	}
+
+	cacheErr := c.cacheSet(ctx, query, data, expire)
+	if cacheErr != nil {
+		c.stats.CacheError(query.Bucket(), cacheErr)
+		cacheErr = fmt.Errorf("write to cache error: %s", cacheErr)
+		if c.isDirectReturnOnCacheFault(query.Bucket()) {
+			return cacheErr
+		}
+		c.log.Error(cacheErr)
+	}
+	return errs.NotFound
+}
@@ -99,3 +99,9 @@ func Marshal(a interface{}) ([]byte, error) {
 	}
 	return jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(a)
 }
+
+// 获取参数的文本, 结果和使用这个参数创建的query的ArgsText一致
+func ArgsText(args interface{}) string {
+	bs, _ := Marshal(args)
+	return string(bs)
+}
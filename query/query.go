@@ -16,6 +16,7 @@ import (
 )
 
 var _ core.IQuery = (*Query)(nil)
+var _ core.IStaleQuery = (*Query)(nil)
 
 type Query struct {
 	// 桶名
@@ -33,7 +34,8 @@ type Query struct {
 
 	loader core.ILoader
 
-	err error
+	err   error
+	stale bool
 }
 
 // 创建一个查询
@@ -53,6 +55,18 @@ func NewQuery(bucket string, opts ...Option) core.IQuery {
 	return q
 }
 
+// 复制一个查询, 保留原查询的参数文本和全局id, 不包含错误和是否为过期数据的状态
+func Clone(query core.IQuery) core.IQuery {
+	return &Query{
+		bucket:   query.Bucket(),
+		args:     query.Args(),
+		argsText: query.ArgsText(),
+		globalId: query.GlobalId(),
+		meta:     query.Meta(),
+		loader:   query.Loader(),
+	}
+}
+
 func (q *Query) makeArgsText() {
 	bs, _ := Marshal(q.args)
 	q.argsText = string(bs)
@@ -94,3 +108,11 @@ func (q *Query) Err() error {
 func (q *Query) SetError(err error) {
 	q.err = err
 }
+
+func (q *Query) IsStale() bool {
+	return q.stale
+}
+
+func (q *Query) SetStale(stale bool) {
+	q.stale = stale
+}
@@ -19,6 +19,7 @@ type QueryConfig struct {
 	meta   interface{}
 	loader core.ILoader
 	err    error
+	stale  bool
 }
 
 // 创建一个查询配置
@@ -57,6 +58,15 @@ func (m *QueryConfig) setError(err error) {
 	m.err = err
 }
 
+// 返回的数据是否为加载失败时使用的过期数据
+func (m *QueryConfig) IsStale() bool {
+	return m.stale
+}
+
+func (m *QueryConfig) setStale(stale bool) {
+	m.stale = stale
+}
+
 // 创建一个查询
 func NewQuery(bucket string, queryConfig ...*QueryConfig) core.IQuery {
 	if len(queryConfig) > 0 {
@@ -9,11 +9,15 @@
 package no_sf
 
 import (
+	"context"
+
 	"github.com/zlyuancn/zcache/core"
 )
 
 type noSingleFlight struct{}
 
+var _ core.IContextBatchSingleFlight = (*noSingleFlight)(nil)
+
 // 一个关闭并发查询控制的ISingleFlight
 func NoSingleFlight() core.ISingleFlight {
 	return new(noSingleFlight)
@@ -22,3 +26,15 @@ func NoSingleFlight() core.ISingleFlight {
 func (*noSingleFlight) Do(query core.IQuery, fn func(core.IQuery) ([]byte, error)) ([]byte, error) {
 	return fn(query)
 }
+
+func (*noSingleFlight) DoBatch(queries []core.IQuery, fn func([]core.IQuery) ([][]byte, []error)) ([][]byte, []error) {
+	return fn(queries)
+}
+
+func (*noSingleFlight) DoWithContext(ctx context.Context, query core.IQuery, fn func(ctx context.Context, query core.IQuery) ([]byte, error)) ([]byte, error) {
+	return fn(ctx, query)
+}
+
+func (*noSingleFlight) DoBatchWithContext(ctx context.Context, queries []core.IQuery, fn func(ctx context.Context, queries []core.IQuery) ([][]byte, []error)) ([][]byte, []error) {
+	return fn(ctx, queries)
+}
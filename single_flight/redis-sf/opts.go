@@ -0,0 +1,78 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package redis_sf
+
+import (
+	"time"
+
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/logger"
+)
+
+type Option func(m *RedisSingleFlight)
+
+// 设置key的前缀
+func WithKeyPrefix(prefix string) Option {
+	return func(m *RedisSingleFlight) {
+		if prefix == "" {
+			prefix = DefaultKeyPrefix
+		}
+		m.keyPrefix = prefix
+	}
+}
+
+// 设置锁的有效时间, 加载时间超过这个时间后其它实例可以抢到锁重新加载
+func WithLockTTL(ttl time.Duration) Option {
+	return func(m *RedisSingleFlight) {
+		if ttl <= 0 {
+			ttl = DefaultLockTTL
+		}
+		m.lockTTL = ttl
+	}
+}
+
+// 设置加载结果的保留时间, 等待的实例在这段时间内可以拿到结果
+func WithResultTTL(ttl time.Duration) Option {
+	return func(m *RedisSingleFlight) {
+		if ttl <= 0 {
+			ttl = DefaultResultTTL
+		}
+		m.resultTTL = ttl
+	}
+}
+
+// 设置等待其它实例加载结果的最长时间, 超时后会在本地加载
+func WithWaitTimeout(timeout time.Duration) Option {
+	return func(m *RedisSingleFlight) {
+		if timeout <= 0 {
+			timeout = DefaultWaitTimeout
+		}
+		m.waitTimeout = timeout
+	}
+}
+
+// 设置等待时检查加载结果的间隔
+func WithPollInterval(interval time.Duration) Option {
+	return func(m *RedisSingleFlight) {
+		if interval <= 0 {
+			interval = DefaultPollInterval
+		}
+		m.pollInterval = interval
+	}
+}
+
+// 设置日志组件
+func WithLogger(log core.ILogger) Option {
+	return func(m *RedisSingleFlight) {
+		if log == nil {
+			log = logger.NoLog()
+		}
+		m.log = log
+	}
+}
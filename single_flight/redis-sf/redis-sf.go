@@ -0,0 +1,338 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package redis_sf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	rredis "github.com/go-redis/redis/v8"
+
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/errs"
+	"github.com/zlyuancn/zcache/logger"
+	single_sf "github.com/zlyuancn/zcache/single_flight/single-sf"
+)
+
+const (
+	// 默认key前缀
+	DefaultKeyPrefix = "zcache:sf:"
+	// 默认锁的有效时间
+	DefaultLockTTL = time.Second * 5
+	// 默认加载结果的保留时间
+	DefaultResultTTL = time.Second * 3
+	// 默认等待其它实例加载结果的最长时间
+	DefaultWaitTimeout = time.Second * 3
+	// 默认检查加载结果的间隔
+	DefaultPollInterval = time.Millisecond * 50
+)
+
+const (
+	resultOk       byte = 0 // 加载成功, 后面是数据
+	resultErr      byte = 1 // 加载失败, 后面是错误信息
+	resultNotFound byte = 2 // 数据不存在
+)
+
+// 只有锁的持有者才能释放锁
+var unlockScript = rredis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+var _ core.IContextBatchSingleFlight = (*RedisSingleFlight)(nil)
+
+// 基于 redis 锁的分布式单跑模块
+//
+// 同一个实例内先通过本地单跑模块去重, 然后多个实例抢同一个锁, 抢到锁的实例执行加载并将结果写入 redis,
+// 其它实例轮询加载结果. 等待超时或 redis 故障时会在本地加载.
+// 批量执行时一次性抢所有query的锁, 抢到锁的query一次性加载, 其它query等待持有锁的实例的结果.
+type RedisSingleFlight struct {
+	client rredis.UniversalClient
+	local  *single_sf.SingleFlight
+	id     string // 实例id, 用于生成锁的值
+
+	keyPrefix    string
+	lockTTL      time.Duration
+	resultTTL    time.Duration
+	waitTimeout  time.Duration
+	pollInterval time.Duration
+
+	log core.ILogger
+}
+
+// 创建一个分布式单跑模块
+func NewRedisSingleFlight(client rredis.UniversalClient, opts ...Option) *RedisSingleFlight {
+	if client == nil {
+		panic(errors.New("redis client is nil"))
+	}
+
+	m := &RedisSingleFlight{
+		client: client,
+		local:  single_sf.NewSingleFlight(),
+		id:     fmt.Sprintf("%d-%d-%d", os.Getpid(), time.Now().UnixNano(), rand.Int63()),
+
+		keyPrefix:    DefaultKeyPrefix,
+		lockTTL:      DefaultLockTTL,
+		resultTTL:    DefaultResultTTL,
+		waitTimeout:  DefaultWaitTimeout,
+		pollInterval: DefaultPollInterval,
+
+		log: logger.NoLog(),
+	}
+	for _, o := range opts {
+		o(m)
+	}
+	return m
+}
+
+func (m *RedisSingleFlight) Do(query core.IQuery, fn func(query core.IQuery) ([]byte, error)) ([]byte, error) {
+	return m.DoWithContext(context.Background(), query, func(_ context.Context, query core.IQuery) ([]byte, error) {
+		return fn(query)
+	})
+}
+
+func (m *RedisSingleFlight) DoWithContext(ctx context.Context, query core.IQuery, fn func(ctx context.Context, query core.IQuery) ([]byte, error)) ([]byte, error) {
+	return m.local.DoWithContext(ctx, query, func(ctx context.Context, query core.IQuery) ([]byte, error) {
+		vs, es := m.doBatch(ctx, []core.IQuery{query}, func(ctx context.Context, queries []core.IQuery) ([][]byte, []error) {
+			bs, err := fn(ctx, queries[0])
+			return [][]byte{bs}, []error{err}
+		})
+		return vs[0], es[0]
+	})
+}
+
+func (m *RedisSingleFlight) DoBatch(queries []core.IQuery, fn func(queries []core.IQuery) ([][]byte, []error)) ([][]byte, []error) {
+	return m.DoBatchWithContext(context.Background(), queries, func(_ context.Context, queries []core.IQuery) ([][]byte, []error) {
+		return fn(queries)
+	})
+}
+
+func (m *RedisSingleFlight) DoBatchWithContext(ctx context.Context, queries []core.IQuery, fn func(ctx context.Context, queries []core.IQuery) ([][]byte, []error)) ([][]byte, []error) {
+	return m.local.DoBatchWithContext(ctx, queries, func(ctx context.Context, queries []core.IQuery) ([][]byte, []error) {
+		return m.doBatch(ctx, queries, fn)
+	})
+}
+
+func (m *RedisSingleFlight) lockKey(query core.IQuery) string {
+	return m.keyPrefix + "lock:" + strconv.FormatUint(query.GlobalId(), 10)
+}
+
+// 加载结果的key, 包含加载者的锁的值, 所以等待的实例不会拿到之前加载的结果
+func (m *RedisSingleFlight) resultKey(query core.IQuery, token string) string {
+	return m.keyPrefix + "result:" + strconv.FormatUint(query.GlobalId(), 10) + ":" + token
+}
+
+func (m *RedisSingleFlight) doBatch(ctx context.Context, queries []core.IQuery, fn func(ctx context.Context, queries []core.IQuery) ([][]byte, []error)) ([][]byte, []error) {
+	vs := make([][]byte, len(queries))
+	es := make([]error, len(queries))
+	token := m.id + "-" + strconv.FormatInt(rand.Int63(), 36)
+
+	deadline := time.Now().Add(m.waitTimeout)
+	pending := make([]int, len(queries))
+	for i := range pending {
+		pending[i] = i
+	}
+	var locals []int // 需要在本地加载的query
+	for len(pending) > 0 {
+		// 抢锁
+		holders, err := m.lock(ctx, queries, pending, token)
+		if err != nil {
+			if ctx.Err() != nil {
+				for _, index := range pending {
+					es[index] = ctx.Err()
+				}
+				return vs, es
+			}
+			m.log.Error(fmt.Errorf("redis single flight lock error, the data will be loaded locally. query: %s, err: %s", queries[pending[0]].Bucket(), err))
+			locals = append(locals, pending...)
+			break
+		}
+
+		var leaders, retries []int
+		for i, index := range pending {
+			switch holders[i] {
+			case token:
+				leaders = append(leaders, index)
+			case "": // 锁已经被释放, 重新抢锁
+				retries = append(retries, index)
+			}
+		}
+		if len(leaders) > 0 {
+			m.lead(ctx, queries, leaders, token, vs, es, fn)
+		}
+
+		// 等待持有锁的实例的结果
+		for i, index := range pending {
+			if holders[i] == token || holders[i] == "" {
+				continue
+			}
+			if !m.wait(ctx, queries[index], holders[i], deadline, &vs[index], &es[index]) {
+				locals = append(locals, index)
+			}
+		}
+		pending = retries
+	}
+
+	if len(locals) > 0 {
+		m.load(ctx, queries, locals, vs, es, fn)
+	}
+	return vs, es
+}
+
+// 一次性抢 indexes 对应的query的锁, 返回每个锁的持有者的锁的值, 抢锁后锁已经被释放的为空字符串
+//
+// 没有抢到的锁会立即获取持有者, 持有者的加载结果以它的锁的值为key, 所以释放锁后仍然可以拿到它的结果
+func (m *RedisSingleFlight) lock(ctx context.Context, queries []core.IQuery, indexes []int, token string) ([]string, error) {
+	lockCmds := make([]*rredis.BoolCmd, len(indexes))
+	_, err := m.client.Pipelined(ctx, func(pipe rredis.Pipeliner) error {
+		for i, index := range indexes {
+			lockCmds[i] = pipe.SetNX(ctx, m.lockKey(queries[index]), token, m.lockTTL)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	holders := make([]string, len(indexes))
+	holderCmds := make([]*rredis.StringCmd, len(indexes))
+	_, err = m.client.Pipelined(ctx, func(pipe rredis.Pipeliner) error {
+		for i, index := range indexes {
+			if lockCmds[i].Val() {
+				holders[i] = token
+				continue
+			}
+			holderCmds[i] = pipe.Get(ctx, m.lockKey(queries[index]))
+		}
+		return nil
+	})
+	if err != nil && err != rredis.Nil {
+		return nil, err
+	}
+	for i, cmd := range holderCmds {
+		if cmd != nil {
+			holders[i] = cmd.Val()
+		}
+	}
+	return holders, nil
+}
+
+// 等待持有锁的实例的加载结果, 拿到结果或者ctx结束时结果写入 v 和 e. 等待超时或redis故障时返回false, 需要在本地加载
+func (m *RedisSingleFlight) wait(ctx context.Context, query core.IQuery, holder string, deadline time.Time, v *[]byte, e *error) bool {
+	resultKey := m.resultKey(query, holder)
+	for {
+		select {
+		case <-ctx.Done():
+			*e = ctx.Err()
+			return true
+		case <-time.After(m.pollInterval):
+		}
+
+		bs, err := m.client.Get(ctx, resultKey).Bytes()
+		switch err {
+		case nil:
+			*v, *e = decodeResult(bs)
+			return true
+		case rredis.Nil:
+		default:
+			if ctx.Err() != nil {
+				*e = ctx.Err()
+				return true
+			}
+			m.log.Error(fmt.Errorf("redis single flight get result error, the data will be loaded locally. query: %s, args: %s, err: %s", query.Bucket(), query.ArgsText(), err))
+			return false
+		}
+
+		if time.Now().After(deadline) {
+			m.log.Error(fmt.Errorf("redis single flight wait timeout, the data will be loaded locally. query: %s, args: %s", query.Bucket(), query.ArgsText()))
+			return false
+		}
+	}
+}
+
+// 抢到锁后一次性加载 indexes 对应的query并写入结果
+func (m *RedisSingleFlight) lead(ctx context.Context, queries []core.IQuery, indexes []int, token string, vs [][]byte, es []error,
+	fn func(ctx context.Context, queries []core.IQuery) ([][]byte, []error)) {
+	m.load(ctx, queries, indexes, vs, es, fn)
+
+	// 结果不受调用者上下文的影响, 否则调用者取消后其它实例只能等待超时
+	bg := context.Background()
+	_, err := m.client.Pipelined(bg, func(pipe rredis.Pipeliner) error {
+		for _, index := range indexes {
+			pipe.Set(bg, m.resultKey(queries[index], token), encodeResult(vs[index], es[index]), m.resultTTL)
+		}
+		return nil
+	})
+	if err != nil {
+		m.log.Error(fmt.Errorf("redis single flight set result error. query: %s, err: %s", queries[indexes[0]].Bucket(), err))
+	}
+	_, err = m.client.Pipelined(bg, func(pipe rredis.Pipeliner) error {
+		for _, index := range indexes {
+			unlockScript.Eval(bg, pipe, []string{m.lockKey(queries[index])}, token)
+		}
+		return nil
+	})
+	if err != nil {
+		m.log.Error(fmt.Errorf("redis single flight unlock error. query: %s, err: %s", queries[indexes[0]].Bucket(), err))
+	}
+}
+
+// 一次性加载 indexes 对应的query, 结果写入 vs 和 es
+func (m *RedisSingleFlight) load(ctx context.Context, queries []core.IQuery, indexes []int, vs [][]byte, es []error,
+	fn func(ctx context.Context, queries []core.IQuery) ([][]byte, []error)) {
+	loadQueries := make([]core.IQuery, len(indexes))
+	for i, index := range indexes {
+		loadQueries[i] = queries[index]
+	}
+
+	loadVs, loadErrs := fn(ctx, loadQueries)
+	if len(loadVs) != len(indexes) || len(loadErrs) != len(indexes) {
+		err := errors.New("batch result is inconsistent with the number of requests")
+		for _, index := range indexes {
+			es[index] = err
+		}
+		return
+	}
+	for i, index := range indexes {
+		vs[index], es[index] = loadVs[i], loadErrs[i]
+	}
+}
+
+func encodeResult(bs []byte, err error) []byte {
+	if errors.Is(err, errs.NotFound) {
+		return []byte{resultNotFound}
+	}
+	if err != nil {
+		return append([]byte{resultErr}, err.Error()...)
+	}
+	return append([]byte{resultOk}, bs...)
+}
+
+func decodeResult(bs []byte) ([]byte, error) {
+	if len(bs) == 0 {
+		return nil, errors.New("redis single flight result is empty")
+	}
+	switch bs[0] {
+	case resultErr:
+		return nil, errors.New(string(bs[1:]))
+	case resultNotFound:
+		return nil, errs.NotFound
+	}
+	if len(bs) == 1 {
+		return nil, nil
+	}
+	return bs[1:], nil
+}
@@ -0,0 +1,39 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package single_sf
+
+import (
+	"errors"
+	"time"
+)
+
+type Option func(m *SingleFlight)
+
+// 设置分片数, 分片数必须为2的幂, count = 0 表示使用默认分片数
+func WithShardCount(count uint64) Option {
+	return func(m *SingleFlight) {
+		if count == 0 {
+			count = ShardCount
+		}
+		if count&(count-1) != 0 {
+			panic(errors.New("shardCount must power of 2"))
+		}
+		m.shardCount = count
+	}
+}
+
+// 设置等待其它调用者结果的最长时间, 超时后返回 ErrWaitTimeout. maxWait <= 0 (默认) 表示一直等待
+func WithMaxWait(maxWait time.Duration) Option {
+	return func(m *SingleFlight) {
+		if maxWait < 0 {
+			maxWait = 0
+		}
+		m.maxWait = maxWait
+	}
+}
@@ -9,8 +9,12 @@
 package single_sf
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/zlyuancn/zcache/core"
 )
@@ -19,84 +23,371 @@ const (
 	ShardCount uint64 = 1 << 5 // 分片数
 )
 
+// 等待其它调用者的结果超时
+var ErrWaitTimeout = errors.New("single flight wait timeout")
+
 type waitResult struct {
-	wg sync.WaitGroup
-	v  []byte
-	e  error
+	done   chan struct{}
+	v      []byte
+	e      error
+	flight *flightContext // 执行fn的上下文, 批量执行时多个结果共用一个
+}
+
+func newWaitResult(flight *flightContext) *waitResult {
+	return &waitResult{done: make(chan struct{}), flight: flight}
 }
 
-var _ core.ISingleFlight = (*SingleFlight)(nil)
+// 统计数据
+type Stats struct {
+	Leaders  uint64 // 执行了fn的调用次数
+	Shared   uint64 // 拿到了其它调用者结果的调用次数
+	Timeouts uint64 // 等待超时的调用次数
+	Canceled uint64 // 等待时ctx结束的调用次数
+	Panics   uint64 // fn发生panic的次数
+	Waiting  uint64 // 正在等待其它调用者结果的调用者数量
+}
+
+var _ core.IContextBatchSingleFlight = (*SingleFlight)(nil)
 
 type SingleFlight struct {
 	mxs        []*sync.RWMutex
 	waits      []map[uint64]*waitResult
 	shardCount uint64
 	shardMod   uint64
+	maxWait    time.Duration
+
+	leaders, shared, timeouts, canceled, panics, waiting uint64
 }
 
 // 创建一个单跑, 分片数必须大于0且为2的幂
 func NewSingleFlight(shardCount ...uint64) *SingleFlight {
-	count := ShardCount
-	if len(shardCount) > 0 && shardCount[0] > 0 {
-		count = shardCount[0]
-		if count&(count-1) != 0 {
-			panic(errors.New("shardCount must power of 2"))
-		}
+	if len(shardCount) > 0 {
+		return NewSingleFlightWithOptions(WithShardCount(shardCount[0]))
+	}
+	return NewSingleFlightWithOptions()
+}
+
+// 根据选项创建一个单跑
+func NewSingleFlightWithOptions(opts ...Option) *SingleFlight {
+	m := &SingleFlight{shardCount: ShardCount}
+	for _, o := range opts {
+		o(m)
+	}
+
+	m.mxs = make([]*sync.RWMutex, m.shardCount)
+	m.waits = make([]map[uint64]*waitResult, m.shardCount)
+	for i := uint64(0); i < m.shardCount; i++ {
+		m.mxs[i] = new(sync.RWMutex)
+		m.waits[i] = make(map[uint64]*waitResult)
+	}
+	m.shardMod = m.shardCount - 1
+	return m
+}
+
+// 获取统计数据
+func (m *SingleFlight) Stats() Stats {
+	return Stats{
+		Leaders:  atomic.LoadUint64(&m.leaders),
+		Shared:   atomic.LoadUint64(&m.shared),
+		Timeouts: atomic.LoadUint64(&m.timeouts),
+		Canceled: atomic.LoadUint64(&m.canceled),
+		Panics:   atomic.LoadUint64(&m.panics),
+		Waiting:  atomic.LoadUint64(&m.waiting),
+	}
+}
+
+// 等待结果, ctx结束或超过最长等待时间时不再等待. 调用前必须已经加入了结果的 flight
+func (m *SingleFlight) wait(ctx context.Context, result *waitResult) ([]byte, error) {
+	var timeout <-chan time.Time
+	if m.maxWait > 0 {
+		timer := time.NewTimer(m.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	atomic.AddUint64(&m.waiting, 1)
+	defer atomic.AddUint64(&m.waiting, ^uint64(0))
+	select {
+	case <-result.done:
+		atomic.AddUint64(&m.shared, 1)
+		return result.v, result.e
+	case <-ctx.Done():
+		atomic.AddUint64(&m.canceled, 1)
+		result.flight.leave(ctx.Err())
+		return nil, ctx.Err()
+	case <-timeout:
+		atomic.AddUint64(&m.timeouts, 1)
+		result.flight.leave(context.DeadlineExceeded)
+		return nil, ErrWaitTimeout
+	}
+}
+
+// 执行fn的上下文, 保留第一个调用者上下文中的值
+//
+// 记录等待结果的调用者数量, 所有调用者都放弃等待后结束, 错误为最后一个放弃的调用者的上下文的错误.
+// 所有调用者的上下文都有截止时间时, 截止时间为其中最晚的那个
+type flightContext struct {
+	parent context.Context // 第一个调用者的上下文, 只用于获取值
+	done   chan struct{}
+
+	mx       sync.Mutex
+	refs     int
+	deadline time.Time // 零值表示没有截止时间
+	err      error
+}
+
+func newFlightContext(parent context.Context) *flightContext {
+	return &flightContext{parent: parent, done: make(chan struct{})}
+}
+
+func (c *flightContext) Deadline() (time.Time, bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.deadline, !c.deadline.IsZero()
+}
+func (c *flightContext) Done() <-chan struct{} { return c.done }
+func (c *flightContext) Err() error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.err
+}
+func (c *flightContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// 加入等待, 上下文已经结束时返回false
+func (c *flightContext) join(ctx context.Context) bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.err != nil {
+		return false
 	}
 
-	mxs := make([]*sync.RWMutex, count)
-	mms := make([]map[uint64]*waitResult, count)
-	for i := uint64(0); i < count; i++ {
-		mxs[i] = new(sync.RWMutex)
-		mms[i] = make(map[uint64]*waitResult)
+	deadline, ok := ctx.Deadline()
+	switch {
+	case c.refs == 0 && ok: // 第一个调用者
+		c.deadline = deadline
+	case !ok: // 有调用者会一直等待
+		c.deadline = time.Time{}
+	case !c.deadline.IsZero() && deadline.After(c.deadline):
+		c.deadline = deadline
 	}
-	return &SingleFlight{
-		mxs:        mxs,
-		waits:      mms,
-		shardCount: count,
-		shardMod:   count - 1,
+	c.refs++
+	return true
+}
+
+// 放弃等待, 所有调用者都放弃等待后以 err 结束上下文
+func (c *flightContext) leave(err error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.refs--
+	if c.refs > 0 || c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+}
+
+// 查找可以加入的结果, 必须持有分片的锁
+func lookup(ctx context.Context, wait map[uint64]*waitResult, id uint64) (*waitResult, bool) {
+	result, ok := wait[id]
+	if ok && result.flight.join(ctx) {
+		return result, true
+	}
+	return nil, false // fn的上下文已经结束时视为没有结果, 重新执行
+}
+
+// 执行fn的上下文, 设置了最长等待时间时, 超过最长等待时间后结束
+func (m *SingleFlight) fnContext(flight *flightContext) (context.Context, context.CancelFunc) {
+	if m.maxWait > 0 {
+		return context.WithTimeout(flight, m.maxWait)
+	}
+	return flight, func() {}
+}
+
+// 将panic转为错误
+func (m *SingleFlight) recoverPanic(err *error) {
+	e := recover()
+	if e == nil {
+		return
 	}
+	atomic.AddUint64(&m.panics, 1)
+	*err = fmt.Errorf("single flight fn panic: %v", e)
 }
 
 func (m *SingleFlight) Do(query core.IQuery, fn func(query core.IQuery) ([]byte, error)) ([]byte, error) {
+	return m.DoWithContext(context.Background(), query, func(_ context.Context, query core.IQuery) ([]byte, error) {
+		return fn(query)
+	})
+}
+
+// 执行加载, fn发生panic时会转为错误返回给所有调用者
+//
+// 等待其他进程的结果时如果ctx结束会直接返回ctx的错误, 超过最长等待时间会返回 ErrWaitTimeout.
+// fn收到的上下文保留第一个调用者上下文中的值, 在所有等待结果的调用者都放弃等待后结束,
+// 所以第一个调用者的ctx结束时会直接返回ctx的错误, 还有其它调用者在等待时fn会在后台继续执行
+func (m *SingleFlight) DoWithContext(ctx context.Context, query core.IQuery, fn func(ctx context.Context, query core.IQuery) ([]byte, error)) ([]byte, error) {
 	shard := query.GlobalId() & m.shardMod
 	mx := m.mxs[shard]
 	wait := m.waits[shard]
 
 	mx.RLock()
-	result, ok := wait[query.GlobalId()]
+	result, ok := lookup(ctx, wait, query.GlobalId())
 	mx.RUnlock()
 
 	// 来晚了, 等待结果
 	if ok {
-		result.wg.Wait()
-		return result.v, result.e
+		return m.wait(ctx, result)
 	}
 
 	mx.Lock()
 
 	// 再检查一下, 因为在拿到锁之前可能被别的进程占了位置
-	result, ok = wait[query.GlobalId()]
+	result, ok = lookup(ctx, wait, query.GlobalId())
 	if ok {
 		mx.Unlock()
-		result.wg.Wait()
-		return result.v, result.e
+		return m.wait(ctx, result)
 	}
 
 	// 占位置
-	result = new(waitResult)
-	result.wg.Add(1)
+	flight := newFlightContext(ctx)
+	flight.join(ctx)
+	result = newWaitResult(flight)
 	wait[query.GlobalId()] = result
 	mx.Unlock()
+	atomic.AddUint64(&m.leaders, 1)
 
-	// 执行db加载
-	result.v, result.e = fn(query)
-	result.wg.Done()
+	run := func() {
+		// 离开, 即使fn发生panic也要通知等待者并让出位置
+		defer func() {
+			m.release(query, result)
+			close(result.done)
+		}()
 
-	// 离开
-	mx.Lock()
-	delete(wait, query.GlobalId())
-	mx.Unlock()
+		// 执行db加载
+		defer m.recoverPanic(&result.e)
+		fnCtx, cancel := m.fnContext(flight)
+		defer cancel()
+		result.v, result.e = fn(fnCtx, query)
+	}
+	if ctx.Done() == nil { // ctx不会结束, 不需要在后台执行
+		run()
+		return result.v, result.e
+	}
+	go run()
+	return m.waitLeader(ctx, result)
+}
+
+// 让出位置, 位置已经被重新执行的调用者占用时不处理
+func (m *SingleFlight) release(query core.IQuery, result *waitResult) {
+	shard := query.GlobalId() & m.shardMod
+	m.mxs[shard].Lock()
+	if m.waits[shard][query.GlobalId()] == result {
+		delete(m.waits[shard], query.GlobalId())
+	}
+	m.mxs[shard].Unlock()
+}
+
+// 等待自己执行的结果, ctx结束时不再等待, 还有其它调用者在等待时fn会继续在后台执行
+func (m *SingleFlight) waitLeader(ctx context.Context, result *waitResult) ([]byte, error) {
+	select {
+	case <-result.done:
+		return result.v, result.e
+	case <-ctx.Done():
+		atomic.AddUint64(&m.canceled, 1)
+		result.flight.leave(ctx.Err())
+		return nil, ctx.Err()
+	}
+}
+
+func (m *SingleFlight) DoBatch(queries []core.IQuery, fn func(queries []core.IQuery) ([][]byte, []error)) ([][]byte, []error) {
+	return m.DoBatchWithContext(context.Background(), queries, func(_ context.Context, queries []core.IQuery) ([][]byte, []error) {
+		return fn(queries)
+	})
+}
 
-	return result.v, result.e
+// 批量执行加载, fn发生panic时会转为错误返回给所有调用者
+//
+// 等待其他进程的结果时如果ctx结束会直接返回ctx的错误, 超过最长等待时间会返回 ErrWaitTimeout.
+// fn收到的上下文和第一个调用者的ctx结束时的行为同 DoWithContext
+func (m *SingleFlight) DoBatchWithContext(ctx context.Context, queries []core.IQuery, fn func(ctx context.Context, queries []core.IQuery) ([][]byte, []error)) ([][]byte, []error) {
+	buffs := make([][]byte, len(queries))
+	es := make([]error, len(queries))
+
+	waits := make([]*waitResult, len(queries)) // 每个query的结果
+	isLeader := make([]bool, len(queries))
+	leaderIndexes := make([]int, 0, len(queries))
+	flight := newFlightContext(ctx) // 占到位置的query共用一个上下文
+	for i, query := range queries {
+		shard := query.GlobalId() & m.shardMod
+		mx := m.mxs[shard]
+		wait := m.waits[shard]
+
+		mx.Lock()
+		result, ok := lookup(ctx, wait, query.GlobalId())
+		if !ok { // 占位置
+			flight.join(ctx)
+			result = newWaitResult(flight)
+			wait[query.GlobalId()] = result
+			leaderIndexes = append(leaderIndexes, i)
+			isLeader[i] = true
+		}
+		mx.Unlock()
+		waits[i] = result
+	}
+
+	// 执行占到位置的query
+	if len(leaderIndexes) > 0 {
+		atomic.AddUint64(&m.leaders, uint64(len(leaderIndexes)))
+		if ctx.Done() == nil {
+			m.doLeaders(flight, queries, waits, leaderIndexes, fn)
+		} else {
+			go m.doLeaders(flight, queries, waits, leaderIndexes, fn)
+		}
+	}
+
+	// 等待结果
+	for i, result := range waits {
+		if isLeader[i] {
+			buffs[i], es[i] = m.waitLeader(ctx, result)
+			continue
+		}
+		buffs[i], es[i] = m.wait(ctx, result)
+	}
+	return buffs, es
+}
+
+// 执行占到位置的query, 即使fn发生panic也会通知等待者并让出位置
+func (m *SingleFlight) doLeaders(flight *flightContext, queries []core.IQuery, waits []*waitResult, leaderIndexes []int,
+	fn func(ctx context.Context, queries []core.IQuery) ([][]byte, []error)) {
+	leaders := make([]core.IQuery, len(leaderIndexes))
+	for i, index := range leaderIndexes {
+		leaders[i] = queries[index]
+	}
+
+	var vs [][]byte
+	var errs []error
+	var err error
+	func() {
+		defer m.recoverPanic(&err)
+		fnCtx, cancel := m.fnContext(flight)
+		defer cancel()
+		vs, errs = fn(fnCtx, leaders)
+	}()
+	if err == nil && (len(vs) != len(leaders) || len(errs) != len(leaders)) {
+		err = errors.New("batch result is inconsistent with the number of requests")
+	}
+	if err != nil {
+		vs, errs = make([][]byte, len(leaders)), make([]error, len(leaders))
+		for i := range errs {
+			errs[i] = err
+		}
+	}
+
+	for i, index := range leaderIndexes {
+		query, result := queries[index], waits[index]
+		result.v, result.e = vs[i], errs[i]
+
+		// 离开
+		m.release(query, result)
+		close(result.done)
+	}
 }
@@ -0,0 +1,48 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package single_flight
+
+import (
+	"context"
+
+	"github.com/zlyuancn/zcache/core"
+)
+
+// 将单跑模块转为支持上下文的单跑模块
+//
+// 如果单跑模块本身不支持上下文, 执行时传入的上下文会原样传给fn, 但等待其它调用者的结果时不会因为上下文结束而返回
+func ToContextSingleFlight(sf core.ISingleFlight) core.IContextSingleFlight {
+	if c, ok := sf.(core.IContextSingleFlight); ok {
+		return c
+	}
+	return &contextSingleFlight{ISingleFlight: sf}
+}
+
+var _ core.IContextBatchSingleFlight = (*contextSingleFlight)(nil)
+
+// 忽略上下文的单跑模块
+type contextSingleFlight struct {
+	core.ISingleFlight
+}
+
+func (c *contextSingleFlight) DoWithContext(ctx context.Context, query core.IQuery, fn func(ctx context.Context, query core.IQuery) ([]byte, error)) ([]byte, error) {
+	return c.Do(query, func(query core.IQuery) ([]byte, error) {
+		return fn(ctx, query)
+	})
+}
+
+func (c *contextSingleFlight) DoBatchWithContext(ctx context.Context, queries []core.IQuery, fn func(ctx context.Context, queries []core.IQuery) ([][]byte, []error)) ([][]byte, []error) {
+	sf, ok := c.ISingleFlight.(core.IBatchSingleFlight)
+	if !ok {
+		return fn(ctx, queries)
+	}
+	return sf.DoBatch(queries, func(queries []core.IQuery) ([][]byte, []error) {
+		return fn(ctx, queries)
+	})
+}
@@ -0,0 +1,31 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package zcache
+
+import (
+	"context"
+
+	"github.com/zlyuancn/zcache/core"
+)
+
+// 开始一个span, query不为nil时会为span设置桶名和参数
+func (c *Cache) startSpan(ctx context.Context, name string, query core.IQuery) (context.Context, core.ISpan) {
+	ctx, span := c.tracer.Start(ctx, name)
+	if query != nil {
+		span.SetAttribute(core.AttrBucket, query.Bucket())
+		span.SetAttribute(core.AttrArgs, query.ArgsText())
+	}
+	return ctx, span
+}
+
+// 记录错误并结束span
+func endSpan(span core.ISpan, err error) {
+	span.RecordError(err)
+	span.End()
+}
@@ -0,0 +1,265 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package memory_stats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zlyuancn/zcache/core"
+)
+
+const (
+	// 默认指标名前缀
+	DefaultNamespace = "zcache"
+)
+
+// 默认加载耗时直方图的桶边界
+var DefaultLatencyBuckets = []time.Duration{
+	time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 500 * time.Millisecond, time.Second, 5 * time.Second,
+}
+
+// 一个bucket的统计数据
+type BucketStats struct {
+	Hits          uint64        // 缓存命中次数
+	Misses        uint64        // 缓存未命中次数
+	LoaderCalls   uint64        // 加载器调用次数
+	LoaderErrors  uint64        // 加载器错误次数
+	LoaderLatency time.Duration // 加载器总耗时
+	CacheErrors   uint64        // 缓存数据库错误次数
+	SharedWaits   uint64        // 等待其它调用者加载结果的次数
+	DecodeErrors  uint64        // 解码错误次数
+}
+
+// 命中率, 没有请求时返回0
+func (s BucketStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// 加载器平均耗时
+func (s BucketStats) AvgLoaderLatency() time.Duration {
+	if s.LoaderCalls == 0 {
+		return 0
+	}
+	return s.LoaderLatency / time.Duration(s.LoaderCalls)
+}
+
+type bucketCounter struct {
+	hits, misses              uint64
+	loaderCalls, loaderErrors uint64
+	loaderLatency             int64 // 纳秒
+	cacheErrors               uint64
+	sharedWaits               uint64
+	decodeErrors              uint64
+	latencyCounts             []uint64 // 每个直方图桶的计数, 不是累计值
+}
+
+var _ core.IStats = (*MemoryStats)(nil)
+var _ http.Handler = (*MemoryStats)(nil)
+
+// 内存统计收集器
+type MemoryStats struct {
+	namespace      string
+	latencyBuckets []time.Duration
+
+	mx      sync.RWMutex
+	buckets map[string]*bucketCounter
+}
+
+// 创建一个内存统计收集器
+func NewMemoryStats(opts ...Option) *MemoryStats {
+	m := &MemoryStats{
+		namespace:      DefaultNamespace,
+		latencyBuckets: DefaultLatencyBuckets,
+		buckets:        make(map[string]*bucketCounter),
+	}
+	for _, o := range opts {
+		o(m)
+	}
+	return m
+}
+
+func (m *MemoryStats) counter(bucket string) *bucketCounter {
+	m.mx.RLock()
+	c, ok := m.buckets[bucket]
+	m.mx.RUnlock()
+	if ok {
+		return c
+	}
+
+	m.mx.Lock()
+	c, ok = m.buckets[bucket]
+	if !ok {
+		c = &bucketCounter{latencyCounts: make([]uint64, len(m.latencyBuckets)+1)}
+		m.buckets[bucket] = c
+	}
+	m.mx.Unlock()
+	return c
+}
+
+func (m *MemoryStats) Hit(bucket string) {
+	atomic.AddUint64(&m.counter(bucket).hits, 1)
+}
+
+func (m *MemoryStats) Miss(bucket string) {
+	atomic.AddUint64(&m.counter(bucket).misses, 1)
+}
+
+func (m *MemoryStats) Load(bucket string, latency time.Duration, err error) {
+	c := m.counter(bucket)
+	atomic.AddUint64(&c.loaderCalls, 1)
+	if err != nil {
+		atomic.AddUint64(&c.loaderErrors, 1)
+	}
+	atomic.AddInt64(&c.loaderLatency, int64(latency))
+
+	// 最后一个桶是 +Inf
+	index := sort.Search(len(m.latencyBuckets), func(i int) bool { return latency <= m.latencyBuckets[i] })
+	atomic.AddUint64(&c.latencyCounts[index], 1)
+}
+
+func (m *MemoryStats) CacheError(bucket string, _ error) {
+	atomic.AddUint64(&m.counter(bucket).cacheErrors, 1)
+}
+
+func (m *MemoryStats) SharedWait(bucket string) {
+	atomic.AddUint64(&m.counter(bucket).sharedWaits, 1)
+}
+
+func (m *MemoryStats) DecodeError(bucket string, _ error) {
+	atomic.AddUint64(&m.counter(bucket).decodeErrors, 1)
+}
+
+// 获取所有bucket的统计数据快照
+func (m *MemoryStats) Stats() map[string]BucketStats {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	result := make(map[string]BucketStats, len(m.buckets))
+	for bucket, c := range m.buckets {
+		result[bucket] = c.snapshot()
+	}
+	return result
+}
+
+// 获取一个bucket的统计数据快照
+func (m *MemoryStats) BucketStats(bucket string) BucketStats {
+	m.mx.RLock()
+	c, ok := m.buckets[bucket]
+	m.mx.RUnlock()
+	if !ok {
+		return BucketStats{}
+	}
+	return c.snapshot()
+}
+
+// 清空统计数据
+func (m *MemoryStats) Reset() {
+	m.mx.Lock()
+	m.buckets = make(map[string]*bucketCounter)
+	m.mx.Unlock()
+}
+
+func (c *bucketCounter) snapshot() BucketStats {
+	return BucketStats{
+		Hits:          atomic.LoadUint64(&c.hits),
+		Misses:        atomic.LoadUint64(&c.misses),
+		LoaderCalls:   atomic.LoadUint64(&c.loaderCalls),
+		LoaderErrors:  atomic.LoadUint64(&c.loaderErrors),
+		LoaderLatency: time.Duration(atomic.LoadInt64(&c.loaderLatency)),
+		CacheErrors:   atomic.LoadUint64(&c.cacheErrors),
+		SharedWaits:   atomic.LoadUint64(&c.sharedWaits),
+		DecodeErrors:  atomic.LoadUint64(&c.decodeErrors),
+	}
+}
+
+// 以 prometheus 文本格式输出统计数据
+func (m *MemoryStats) WritePrometheus(w io.Writer) error {
+	m.mx.RLock()
+	names := make([]string, 0, len(m.buckets))
+	counters := make(map[string]*bucketCounter, len(m.buckets))
+	for bucket, c := range m.buckets {
+		names = append(names, bucket)
+		counters[bucket] = c
+	}
+	m.mx.RUnlock()
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	counterMetrics := []struct {
+		name, help string
+		value      func(s BucketStats) uint64
+	}{
+		{"hits_total", "Number of cache hits.", func(s BucketStats) uint64 { return s.Hits }},
+		{"misses_total", "Number of cache misses.", func(s BucketStats) uint64 { return s.Misses }},
+		{"loader_calls_total", "Number of loader calls.", func(s BucketStats) uint64 { return s.LoaderCalls }},
+		{"loader_errors_total", "Number of loader errors.", func(s BucketStats) uint64 { return s.LoaderErrors }},
+		{"cache_errors_total", "Number of cache db errors.", func(s BucketStats) uint64 { return s.CacheErrors }},
+		{"singleflight_shared_total", "Number of requests that waited for the result of another caller.", func(s BucketStats) uint64 { return s.SharedWaits }},
+		{"decode_errors_total", "Number of decode errors.", func(s BucketStats) uint64 { return s.DecodeErrors }},
+	}
+
+	snapshots := make([]BucketStats, len(names))
+	for i, bucket := range names {
+		snapshots[i] = counters[bucket].snapshot()
+	}
+	for _, metric := range counterMetrics {
+		name := m.namespace + "_" + metric.name
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s counter\n", name, metric.help, name)
+		for i, bucket := range names {
+			fmt.Fprintf(bw, "%s{bucket=\"%s\"} %d\n", name, escapeLabel(bucket), metric.value(snapshots[i]))
+		}
+	}
+
+	// 加载耗时直方图
+	name := m.namespace + "_loader_duration_seconds"
+	fmt.Fprintf(bw, "# HELP %s Latency of loader calls.\n# TYPE %s histogram\n", name, name)
+	for i, bucket := range names {
+		c, label := counters[bucket], escapeLabel(bucket)
+		var cumulative uint64
+		for j, le := range m.latencyBuckets {
+			cumulative += atomic.LoadUint64(&c.latencyCounts[j])
+			fmt.Fprintf(bw, "%s_bucket{bucket=\"%s\",le=\"%s\"} %d\n", name, label, formatSeconds(le), cumulative)
+		}
+		cumulative += atomic.LoadUint64(&c.latencyCounts[len(m.latencyBuckets)])
+		fmt.Fprintf(bw, "%s_bucket{bucket=\"%s\",le=\"+Inf\"} %d\n", name, label, cumulative)
+		fmt.Fprintf(bw, "%s_sum{bucket=\"%s\"} %s\n", name, label, formatSeconds(snapshots[i].LoaderLatency))
+		fmt.Fprintf(bw, "%s_count{bucket=\"%s\"} %d\n", name, label, cumulative)
+	}
+	return bw.Flush()
+}
+
+// 以 prometheus 文本格式响应统计数据, 可以直接注册为 /metrics 接口
+func (m *MemoryStats) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.WritePrometheus(w)
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
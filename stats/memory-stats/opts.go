@@ -0,0 +1,38 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package memory_stats
+
+import (
+	"sort"
+	"time"
+)
+
+type Option func(m *MemoryStats)
+
+// 设置指标名的前缀, 默认为 zcache
+func WithNamespace(namespace string) Option {
+	return func(m *MemoryStats) {
+		if namespace == "" {
+			namespace = DefaultNamespace
+		}
+		m.namespace = namespace
+	}
+}
+
+// 设置加载耗时直方图的桶边界
+func WithLatencyBuckets(buckets ...time.Duration) Option {
+	return func(m *MemoryStats) {
+		if len(buckets) == 0 {
+			buckets = DefaultLatencyBuckets
+		}
+		bs := append([]time.Duration(nil), buckets...)
+		sort.Slice(bs, func(i, j int) bool { return bs[i] < bs[j] })
+		m.latencyBuckets = bs
+	}
+}
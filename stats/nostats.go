@@ -0,0 +1,29 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package stats
+
+import (
+	"time"
+
+	"github.com/zlyuancn/zcache/core"
+)
+
+var _ core.IStats = (*noStats)(nil)
+
+type noStats struct{}
+
+// 一个不做任何统计的IStats
+func NoStats() core.IStats { return new(noStats) }
+
+func (*noStats) Hit(string)                        {}
+func (*noStats) Miss(string)                       {}
+func (*noStats) Load(string, time.Duration, error) {}
+func (*noStats) CacheError(string, error)          {}
+func (*noStats) SharedWait(string)                 {}
+func (*noStats) DecodeError(string, error)         {}
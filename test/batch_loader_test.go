@@ -0,0 +1,122 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+)
+
+func TestBatchLoader(t *testing.T) {
+	cache := zcache.NewCache()
+	const bucket = "test"
+
+	var calls, loaded int32
+	cache.RegisterBatchLoaderFn(bucket, func(queries []zcache.IQuery) (map[int]interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		atomic.AddInt32(&loaded, int32(len(queries)))
+		results := make(map[int]interface{}, len(queries))
+		for i, q := range queries {
+			if q.ArgsText() == "404" { // 不返回的数据视为nil
+				continue
+			}
+			results[i] = "v" + q.ArgsText()
+		}
+		return results, nil
+	}, zcache.WithLoaderBatchSize(2))
+
+	qcs := []*zcache.QueryConfig{
+		zcache.QC().Args(1), zcache.QC().Args(2), zcache.QC().Args(3),
+		zcache.QC().Args(1), zcache.QC().Args(4), zcache.QC().Args(5),
+	}
+	var results []string
+	require.NoError(t, cache.MQuery(bucket, &results, qcs...))
+	require.Equal(t, []string{"v1", "v2", "v3", "v1", "v4", "v5"}, results)
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls)) // 5个不重复的query, 每批2个
+	require.Equal(t, int32(5), atomic.LoadInt32(&loaded))
+
+	// 数据已经写入缓存
+	results = nil
+	require.NoError(t, cache.MQuery(bucket, &results, qcs...))
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// 单条获取也会使用批量加载函数
+	var result string
+	require.NoError(t, cache.Query(bucket, &result, zcache.QC().Args(6)))
+	require.Equal(t, "v6", result)
+	require.Equal(t, int32(4), atomic.LoadInt32(&calls))
+
+	// 结果中不存在的数据
+	qc := zcache.QC().Args(404)
+	results = nil
+	err := cache.MQuery(bucket, &results, zcache.QC().Args(7), qc)
+	require.Error(t, err)
+	require.Equal(t, zcache.DataIsNil, qc.GetErr())
+	require.Equal(t, "v7", results[0])
+}
+
+func TestBatchLoaderSingleFlight(t *testing.T) {
+	cache := makeMemoryCache()
+	const bucket = "test"
+
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	cache.RegisterBatchLoaderFn(bucket, func(queries []zcache.IQuery) (map[int]interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-release
+		}
+		results := make(map[int]interface{}, len(queries))
+		for i, q := range queries {
+			results[i] = "v" + q.ArgsText()
+		}
+		return results, nil
+	})
+
+	type mResult struct {
+		vs  []string
+		err error
+	}
+	mDone := make(chan mResult, 1)
+	go func() {
+		var results []string
+		err := cache.MQuery(bucket, &results, zcache.QC().Args(1), zcache.QC().Args(2))
+		mDone <- mResult{results, err}
+	}()
+
+	// 批量加载进行中, 单条获取会等待批量加载的结果
+	<-started
+	type result struct {
+		v   string
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var v string
+		err := cache.Query(bucket, &v, zcache.QC().Args(2))
+		done <- result{v, err}
+	}()
+	time.Sleep(time.Millisecond * 50)
+	close(release)
+
+	m := <-mDone
+	require.NoError(t, m.err)
+	require.Equal(t, []string{"v1", "v2"}, m.vs)
+	r := <-done
+	require.NoError(t, r.err)
+	require.Equal(t, "v2", r.v)
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,129 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/cachedb/memory-cache"
+	"github.com/zlyuancn/zcache/codec"
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/errs"
+)
+
+// 读取总是失败的缓存数据库
+type faultCacheDB struct {
+	core.ICacheDB
+}
+
+func (f *faultCacheDB) Get(query core.IQuery) ([]byte, error) {
+	return nil, errors.New("fault")
+}
+
+func TestBucketConfig(t *testing.T) {
+	globalDB, userDB := memory_cache.NewMemoryCache(), memory_cache.NewMemoryCache()
+	cache := zcache.NewCache(
+		zcache.WithCacheDB(globalDB),
+		zcache.WithBucket("user", zcache.BucketConfig{
+			Loader: zcache.NewLoader(func(query zcache.IQuery) (interface{}, error) {
+				return map[string]string{"id": query.ArgsText()}, nil
+			}),
+			Codec:   codec.Json,
+			CacheDB: userDB,
+		}),
+	)
+	defer cache.Close()
+	cache.RegisterLoaderFn("other", func(query zcache.IQuery) (interface{}, error) {
+		return "v" + query.ArgsText(), nil
+	})
+
+	// 加载的数据使用bucket的编解码器写入bucket的缓存数据库
+	var user map[string]string
+	require.NoError(t, cache.Query("user", &user, zcache.QC().Args(1)))
+	require.Equal(t, "1", user["id"])
+	bs, err := userDB.Get(zcache.Q("user", zcache.QC().Args(1)))
+	require.NoError(t, err)
+	require.True(t, json.Valid(bs))
+	_, err = globalDB.Get(zcache.Q("user", zcache.QC().Args(1)))
+	require.Equal(t, errs.CacheMiss, err)
+
+	// 没有配置的bucket使用全局配置
+	var result string
+	require.NoError(t, cache.Query("other", &result, zcache.QC().Args(1)))
+	_, err = globalDB.Get(zcache.Q("other", zcache.QC().Args(1)))
+	require.NoError(t, err)
+
+	// 批量获取和写入
+	require.NoError(t, cache.MSave("user", []interface{}{map[string]string{"id": "x"}}, 0, zcache.QC().Args(2)))
+	var users []map[string]string
+	require.NoError(t, cache.MQuery("user", &users, zcache.QC().Args(1), zcache.QC().Args(2), zcache.QC().Args(3)))
+	require.Equal(t, []string{"1", "x", "3"}, []string{users[0]["id"], users[1]["id"], users[2]["id"]})
+	_, err = userDB.Get(zcache.Q("user", zcache.QC().Args(3)))
+	require.NoError(t, err)
+
+	// 删除bucket只作用于bucket的缓存数据库
+	require.NoError(t, cache.DelBucket("user", "other"))
+	_, err = userDB.Get(zcache.Q("user", zcache.QC().Args(1)))
+	require.Equal(t, errs.CacheMiss, err)
+	_, err = globalDB.Get(zcache.Q("other", zcache.QC().Args(1)))
+	require.Equal(t, errs.CacheMiss, err)
+}
+
+func TestBucketConfigExpire(t *testing.T) {
+	cache := zcache.NewCache(zcache.WithDefaultExpire(time.Hour))
+	cache.RegisterBucket("test", zcache.BucketConfig{Expire: time.Millisecond * 100})
+
+	var calls int32
+	cache.RegisterLoaderFn("test", func(query zcache.IQuery) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return "v", nil
+	})
+
+	var result string
+	require.NoError(t, cache.Query("test", &result))
+	require.NoError(t, cache.Query("test", &result))
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 使用bucket的过期时间
+	time.Sleep(time.Millisecond * 150)
+	require.NoError(t, cache.Query("test", &result))
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// 传入的过期时间优先
+	require.NoError(t, cache.Save("test", "v2", time.Hour))
+	time.Sleep(time.Millisecond * 150)
+	require.NoError(t, cache.Query("test", &result))
+	require.Equal(t, "v2", result)
+}
+
+func TestBucketConfigDirectReturnOnCacheFault(t *testing.T) {
+	db := &faultCacheDB{ICacheDB: memory_cache.NewMemoryCache()}
+	b := false
+	cache := zcache.NewCache(
+		zcache.WithCacheDB(db),
+		zcache.WithBucket("fallback", zcache.BucketConfig{DirectReturnOnCacheFault: &b}),
+	)
+	loader := func(query zcache.IQuery) (interface{}, error) {
+		return "v", nil
+	}
+	cache.RegisterLoaderFn("fallback", loader)
+	cache.RegisterLoaderFn("direct", loader)
+
+	var result string
+	require.Error(t, cache.Query("direct", &result))
+	require.NoError(t, cache.Query("fallback", &result))
+	require.Equal(t, "v", result)
+}
@@ -0,0 +1,96 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+)
+
+type bucketUser struct {
+	Id   int
+	Name string
+}
+
+func TestBucket(t *testing.T) {
+	cache := zcache.NewCache()
+	ctx := context.Background()
+
+	var loadCount int32
+	users := zcache.NewBucket(cache, "user", func(ctx context.Context, id int) (*bucketUser, error) {
+		atomic.AddInt32(&loadCount, 1)
+		if id < 0 {
+			return nil, errors.New("invalid id")
+		}
+		return &bucketUser{Id: id, Name: fmt.Sprint("user", id)}, nil
+	})
+	require.Equal(t, "user", users.Name())
+
+	// 加载并写入缓存
+	u, err := users.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, &bucketUser{Id: 1, Name: "user1"}, u)
+	u, err = users.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "user1", u.Name)
+	require.Equal(t, int32(1), atomic.LoadInt32(&loadCount))
+
+	// 和非类型化的api共享数据
+	var raw bucketUser
+	require.NoError(t, cache.Query("user", &raw, zcache.QC().Args(1)))
+	require.Equal(t, "user1", raw.Name)
+
+	// 批量获取
+	us, err := users.MGet(ctx, []int{1, 2, 3})
+	require.NoError(t, err)
+	require.Len(t, us, 3)
+	for i, u := range us {
+		require.Equal(t, i+1, u.Id)
+	}
+
+	// 部分错误
+	us, err = users.MGet(ctx, []int{4, -1})
+	require.Error(t, err)
+	es, ok := zcache.DecodeErrors(err)
+	require.True(t, ok)
+	require.NoError(t, es.Errs()[0])
+	require.Error(t, es.Errs()[1])
+	require.Equal(t, 4, us[0].Id)
+
+	// 设置和删除
+	require.NoError(t, users.Set(ctx, 10, &bucketUser{Id: 10, Name: "custom"}))
+	u, err = users.Get(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, "custom", u.Name)
+	require.NoError(t, users.Del(ctx, 10))
+	u, err = users.Get(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, "user10", u.Name)
+}
+
+func TestBucketWithoutLoader(t *testing.T) {
+	cache := zcache.NewCache()
+	ctx := context.Background()
+
+	names := zcache.NewBucket[string, string](cache, "name", nil)
+	_, err := names.Get(ctx, "a")
+	require.Equal(t, zcache.LoaderNotFound, err)
+
+	require.NoError(t, names.Set(ctx, "a", "v"))
+	v, err := names.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, "v", v)
+}
@@ -20,8 +20,10 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/zlyuancn/zcache"
+	lru_cache "github.com/zlyuancn/zcache/cachedb/lru-cache"
 	"github.com/zlyuancn/zcache/cachedb/memory-cache"
 	redis_cache "github.com/zlyuancn/zcache/cachedb/redis-cache"
+	two_level_cache "github.com/zlyuancn/zcache/cachedb/two-level-cache"
 	"github.com/zlyuancn/zcache/codec"
 )
 
@@ -48,6 +50,20 @@ func makeRedisCache() *zcache.Cache {
 	)
 }
 
+func makeTwoLevelCache() *zcache.Cache {
+	return zcache.NewCache(
+		zcache.WithCacheDB(two_level_cache.NewTwoLevelCache(memory_cache.NewMemoryCache())),
+		zcache.WithCodec(codec.Byte),
+	)
+}
+
+func makeLruCache() *zcache.Cache {
+	return zcache.NewCache(
+		zcache.WithCacheDB(lru_cache.NewLruCache()),
+		zcache.WithCodec(codec.Byte),
+	)
+}
+
 func TestMemoryCache(t *testing.T) {
 	t.Run("Get", func(t *testing.T) {
 		cache := makeMemoryCache()
@@ -94,6 +110,52 @@ func TestRedisCacheGet(t *testing.T) {
 	})
 }
 
+func TestTwoLevelCache(t *testing.T) {
+	t.Run("Get", func(t *testing.T) {
+		cache := makeTwoLevelCache()
+		testCacheGet(t, cache)
+	})
+	t.Run("Set", func(t *testing.T) {
+		cache := makeTwoLevelCache()
+		testCacheSet(t, cache)
+	})
+	t.Run("Del", func(t *testing.T) {
+		cache := makeTwoLevelCache()
+		testCacheDel(t, cache)
+	})
+	t.Run("DelBucket", func(t *testing.T) {
+		cache := makeTwoLevelCache()
+		testCacheDelBucket(t, cache)
+	})
+	t.Run("Expire", func(t *testing.T) {
+		cache := makeTwoLevelCache()
+		testCacheExpire(t, cache)
+	})
+}
+
+func TestLruCache(t *testing.T) {
+	t.Run("Get", func(t *testing.T) {
+		cache := makeLruCache()
+		testCacheGet(t, cache)
+	})
+	t.Run("Set", func(t *testing.T) {
+		cache := makeLruCache()
+		testCacheSet(t, cache)
+	})
+	t.Run("Del", func(t *testing.T) {
+		cache := makeLruCache()
+		testCacheDel(t, cache)
+	})
+	t.Run("DelBucket", func(t *testing.T) {
+		cache := makeLruCache()
+		testCacheDelBucket(t, cache)
+	})
+	t.Run("Expire", func(t *testing.T) {
+		cache := makeLruCache()
+		testCacheExpire(t, cache)
+	})
+}
+
 func testCacheGet(t *testing.T, cache *zcache.Cache) {
 	const bucket = "test"
 	cache.RegisterLoaderFn(bucket, func(query zcache.IQuery) (i interface{}, err error) {
@@ -209,6 +271,10 @@ func BenchmarkMemoryCache_10k(b *testing.B) {
 	benchmarkAny(b, makeMemoryCache(), 1e4)
 }
 
+func BenchmarkLruCache_10k(b *testing.B) {
+	benchmarkAny(b, makeLruCache(), 1e4)
+}
+
 func BenchmarkRedisCache_10k(b *testing.B) {
 	benchmarkAny(b, makeRedisCache(), 1e4)
 }
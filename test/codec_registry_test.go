@@ -0,0 +1,129 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	memory_cache "github.com/zlyuancn/zcache/cachedb/memory-cache"
+	"github.com/zlyuancn/zcache/codec"
+	"github.com/zlyuancn/zcache/envelope"
+)
+
+type codecTestData struct {
+	A string `json:"a"`
+	B int    `json:"b"`
+}
+
+type customCodec struct {
+	id uint8
+}
+
+func (c *customCodec) CodecId() uint8 { return c.id }
+func (c *customCodec) Encode(a interface{}) ([]byte, error) {
+	return codec.Json.Encode(a)
+}
+func (c *customCodec) Decode(data []byte, a interface{}) error {
+	return codec.Json.Decode(data, a)
+}
+
+func TestCodecMigration(t *testing.T) {
+	db := memory_cache.NewMemoryCache()
+	old := zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(codec.MsgPack), zcache.WithEnvelope())
+	cur := zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(codec.Json), zcache.WithEnvelope())
+
+	// 修改编码器后可以读取旧数据
+	require.NoError(t, old.Save("test", &codecTestData{A: "a", B: 1}, 0, zcache.QC().Args(1)))
+	e, err := cur.GetEnvelope(zcache.Q("test", zcache.QC().Args(1)))
+	require.NoError(t, err)
+	require.Equal(t, codec.MsgPackId, e.CodecId)
+
+	var result codecTestData
+	require.NoError(t, cur.Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, codecTestData{A: "a", B: 1}, result)
+
+	// 批量获取
+	require.NoError(t, cur.Save("test", &codecTestData{A: "b", B: 2}, 0, zcache.QC().Args(2)))
+	var results []codecTestData
+	require.NoError(t, cur.MQuery("test", &results, zcache.QC().Args(1), zcache.QC().Args(2)))
+	require.Equal(t, []codecTestData{{A: "a", B: 1}, {A: "b", B: 2}}, results)
+
+	// 没有升级的实例也可以读取新数据
+	result = codecTestData{}
+	require.NoError(t, old.Query("test", &result, zcache.QC().Args(2)))
+	require.Equal(t, codecTestData{A: "b", B: 2}, result)
+}
+
+func TestCodecMigrationWithoutEnvelopeOption(t *testing.T) {
+	db := memory_cache.NewMemoryCache()
+	old := zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(codec.MsgPack), zcache.WithGracePeriod(time.Minute))
+	cur := zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(codec.Json), zcache.WithGracePeriod(time.Minute))
+
+	// 没有开启数据信封, 但数据因为过期数据保留被打包为信封格式时也会记录编解码器id
+	require.NoError(t, old.Save("test", &codecTestData{A: "a", B: 1}, time.Minute, zcache.QC().Args(1)))
+	e, err := cur.GetEnvelope(zcache.Q("test", zcache.QC().Args(1)))
+	require.NoError(t, err)
+	require.Equal(t, codec.MsgPackId, e.CodecId)
+
+	var result codecTestData
+	require.NoError(t, cur.Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, codecTestData{A: "a", B: 1}, result)
+}
+
+func TestCodecFallback(t *testing.T) {
+	db := memory_cache.NewMemoryCache()
+	old := zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(codec.MsgPack))
+	require.NoError(t, old.Save("test", &codecTestData{A: "a", B: 1}, 0, zcache.QC().Args(1)))
+
+	// 没有编解码器id的旧数据无法解码
+	var result codecTestData
+	cur := zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(codec.Json))
+	require.Error(t, cur.Query("test", &result, zcache.QC().Args(1)))
+
+	// 使用备用编解码器解码
+	cur = zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(codec.Json), zcache.WithFallbackCodecs(codec.MsgPack))
+	require.NoError(t, cur.Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, codecTestData{A: "a", B: 1}, result)
+}
+
+func TestCodecRegistry(t *testing.T) {
+	require.Equal(t, codec.MsgPack, codec.GetCodec(codec.MsgPackId))
+	require.Nil(t, codec.GetCodec(codec.MinCustomId+100))
+
+	// 自定义编码器只记录在使用它的缓存中, 不会注册到全局注册表
+	db := memory_cache.NewMemoryCache()
+	local := &customCodec{id: codec.MinCustomId + 2}
+	cache := zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(local), zcache.WithEnvelope())
+	require.Nil(t, codec.GetCodec(local.id))
+	require.NoError(t, cache.Save("local", &codecTestData{A: "a"}, 0))
+	var result codecTestData
+	other := zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(codec.MsgPack))
+	require.Error(t, other.Query("local", &result))
+
+	// 注册后其它缓存也可以解码
+	c := &customCodec{id: codec.MinCustomId + 1}
+	codec.Register(c)
+	require.Equal(t, c, codec.GetCodec(c.id))
+	cache = zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(c), zcache.WithEnvelope())
+	require.NoError(t, cache.Save("custom", &codecTestData{A: "a"}, 0))
+	require.NoError(t, other.Query("custom", &result))
+	require.Equal(t, codecTestData{A: "a"}, result)
+	require.Panics(t, func() { codec.Register(&customCodec{id: codec.MsgPackId}) })
+	require.Panics(t, func() { codec.Register(&customCodec{id: 0}) })
+
+	// 未注册的编解码器id
+	cache = zcache.NewCache(zcache.WithCacheDB(db))
+	bs := envelope.Encode(&envelope.Envelope{CodecId: codec.MinCustomId + 100, Data: []byte("{}")})
+	require.NoError(t, db.Set(zcache.Q("test"), bs, 0))
+	require.Error(t, cache.Query("test", &result))
+}
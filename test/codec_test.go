@@ -0,0 +1,137 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/codec"
+	"github.com/zlyuancn/zcache/core"
+)
+
+type gobShape interface {
+	Area() int
+}
+
+type gobRect struct {
+	W, H int
+}
+
+func (r *gobRect) Area() int { return r.W * r.H }
+
+type gobTestData struct {
+	Name  string
+	Shape gobShape
+}
+
+type xmlTestData struct {
+	Name string   `xml:"name,attr"`
+	Tags []string `xml:"tag"`
+}
+
+func TestGobCodec(t *testing.T) {
+	codec.RegisterGobType(&gobRect{})
+
+	data := &gobTestData{Name: "a", Shape: &gobRect{W: 2, H: 3}}
+	bs, err := codec.Gob.Encode(data)
+	require.NoError(t, err)
+	var result gobTestData
+	require.NoError(t, codec.Gob.Decode(bs, &result))
+	require.Equal(t, *data, result)
+	require.Equal(t, 6, result.Shape.Area())
+
+	cache := zcache.NewCache(zcache.WithCodec(codec.Gob))
+	require.NoError(t, cache.Save("test", data, 0))
+	result = gobTestData{}
+	require.NoError(t, cache.Query("test", &result))
+	require.Equal(t, *data, result)
+}
+
+func TestXmlCodec(t *testing.T) {
+	data := &xmlTestData{Name: "a", Tags: []string{"b", "c"}}
+	bs, err := codec.Xml.Encode(data)
+	require.NoError(t, err)
+	require.Equal(t, `<xmlTestData name="a"><tag>b</tag><tag>c</tag></xmlTestData>`, string(bs))
+	var result xmlTestData
+	require.NoError(t, codec.Xml.Decode(bs, &result))
+	require.Equal(t, *data, result)
+}
+
+func TestProtoBufferV2Codec(t *testing.T) {
+	data, err := structpb.NewStruct(map[string]interface{}{"a": 1, "b": "2", "c": []interface{}{true}})
+	require.NoError(t, err)
+	bs, err := codec.ProtoBufferV2.Encode(data)
+	require.NoError(t, err)
+	var result structpb.Struct
+	require.NoError(t, codec.ProtoBufferV2.Decode(bs, &result))
+	require.True(t, proto.Equal(data, &result))
+
+	_, err = codec.ProtoBufferV2.Encode("not message")
+	require.Error(t, err)
+
+	// 确定性编码
+	c := codec.NewProtoBufferV2Codec(codec.WithProtoDeterministic())
+	first, err := c.Encode(data)
+	require.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		bs, err = c.Encode(data)
+		require.NoError(t, err)
+		require.Equal(t, first, bs)
+	}
+
+	// 未知字段默认保留, 重新编码时原样写回
+	bs, err = codec.ProtoBufferV2.Encode(wrapperspb.String("v"))
+	require.NoError(t, err)
+	var empty emptypb.Empty
+	require.NoError(t, codec.ProtoBufferV2.Decode(bs, &empty))
+	reencoded, err := codec.ProtoBufferV2.Encode(&empty)
+	require.NoError(t, err)
+	require.Equal(t, bs, reencoded)
+
+	// 丢弃未知字段
+	empty = emptypb.Empty{}
+	c = codec.NewProtoBufferV2Codec(codec.WithProtoDiscardUnknown())
+	require.NoError(t, c.Decode(bs, &empty))
+	reencoded, err = c.Encode(&empty)
+	require.NoError(t, err)
+	require.Empty(t, reencoded)
+}
+
+func BenchmarkProtoBufferCodec(b *testing.B) {
+	data, err := structpb.NewStruct(map[string]interface{}{"a": 1, "b": "2", "c": []interface{}{true, "d"}})
+	if err != nil {
+		b.Fatal(err)
+	}
+	codecs := []struct {
+		name  string
+		codec core.ICodec
+	}{
+		{"ProtoBuffer", codec.ProtoBuffer},
+		{"ProtoBufferV2", codec.ProtoBufferV2},
+		{"ProtoBufferV2Deterministic", codec.NewProtoBufferV2Codec(codec.WithProtoDeterministic())},
+	}
+	for _, c := range codecs {
+		b.Run(c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bs, _ := c.codec.Encode(data)
+				var result structpb.Struct
+				if err := c.codec.Decode(bs, &result); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	memory_cache "github.com/zlyuancn/zcache/cachedb/memory-cache"
+	"github.com/zlyuancn/zcache/codec"
+	"github.com/zlyuancn/zcache/core"
+)
+
+type compressTestData struct {
+	Title string   `json:"title"`
+	Body  string   `json:"body"`
+	Tags  []string `json:"tags"`
+}
+
+func makeCompressTestData(size int) *compressTestData {
+	return &compressTestData{
+		Title: "title",
+		Body:  strings.Repeat("zcache compress codec test body. ", size/33+1),
+		Tags:  []string{"a", "b", "c"},
+	}
+}
+
+func TestCompressCodec(t *testing.T) {
+	for _, algorithm := range []codec.CompressAlgorithm{codec.Gzip, codec.Flate, codec.Zlib} {
+		c := codec.NewCompressCodec(codec.MsgPack, codec.WithCompressAlgorithm(algorithm), codec.WithCompressThreshold(512))
+
+		// 超过阈值的数据会被压缩
+		big := makeCompressTestData(10240)
+		bs, err := c.Encode(big)
+		require.NoError(t, err)
+		require.Equal(t, byte(algorithm), bs[0])
+		raw, _ := codec.MsgPack.Encode(big)
+		require.Less(t, len(bs), len(raw)/5)
+
+		var result compressTestData
+		require.NoError(t, c.Decode(bs, &result))
+		require.Equal(t, *big, result)
+
+		// 小于阈值的数据不会被压缩
+		small := makeCompressTestData(10)
+		bs, err = c.Encode(small)
+		require.NoError(t, err)
+		require.Equal(t, byte(0), bs[0])
+		result = compressTestData{}
+		require.NoError(t, c.Decode(bs, &result))
+		require.Equal(t, *small, result)
+	}
+
+	// 解码时不依赖压缩参数
+	bs, err := codec.NewCompressCodec(codec.Json, codec.WithCompressAlgorithm(codec.Zlib), codec.WithCompressThreshold(0)).Encode("v")
+	require.NoError(t, err)
+	var s string
+	require.NoError(t, codec.NewCompressCodec(codec.Json).Decode(bs, &s))
+	require.Equal(t, "v", s)
+}
+
+func TestCompressCodecCache(t *testing.T) {
+	db := memory_cache.NewMemoryCache()
+	c := codec.NewCompressCodec(codec.MsgPack, codec.WithCompressThreshold(100))
+	require.Equal(t, codec.CompressIdOffset+codec.MsgPackId, codec.IdOf(c))
+	writer := zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(c), zcache.WithEnvelope())
+	reader := zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(codec.Json))
+
+	// 没有开启压缩的实例也可以读取压缩的数据
+	data := makeCompressTestData(1024)
+	require.NoError(t, writer.Save("test", data, 0))
+	var result compressTestData
+	require.NoError(t, reader.Query("test", &result))
+	require.Equal(t, *data, result)
+}
+
+func benchmarkCodec(b *testing.B, c core.ICodec, size int) {
+	data := makeCompressTestData(size)
+	bs, err := c.Encode(data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportMetric(float64(len(bs)), "bytes")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bs, _ = c.Encode(data)
+		var result compressTestData
+		if err = c.Decode(bs, &result); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCodec(b *testing.B) {
+	codecs := []struct {
+		name  string
+		codec core.ICodec
+	}{
+		{"Json", codec.Json},
+		{"JsonIterator", codec.JsonIterator},
+		{"MsgPack", codec.MsgPack},
+		{"Gob", codec.Gob},
+		{"Xml", codec.Xml},
+		{"GzipMsgPack", codec.NewCompressCodec(codec.MsgPack)},
+		{"FlateMsgPack", codec.NewCompressCodec(codec.MsgPack, codec.WithCompressAlgorithm(codec.Flate))},
+		{"ZlibMsgPack", codec.NewCompressCodec(codec.MsgPack, codec.WithCompressAlgorithm(codec.Zlib))},
+		{"GzipBestSpeedMsgPack", codec.NewCompressCodec(codec.MsgPack, codec.WithCompressLevel(1))},
+	}
+	for _, size := range []int{512, 50 * 1024, 200 * 1024} {
+		for _, c := range codecs {
+			b.Run(c.name+"_"+strconv.Itoa(size), func(b *testing.B) {
+				benchmarkCodec(b, c.codec, size)
+			})
+		}
+	}
+}
@@ -0,0 +1,170 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/config"
+)
+
+func TestNewCacheFromConfig(t *testing.T) {
+	s := miniredis.RunT(t)
+	text := fmt.Sprintf(`{
+	"cache_db": {"type": "redis", "redis": {"addrs": ["%s"], "key_prefix": "app:", "args_sep": "/", "do_timeout": "1s"}},
+	"codec": "json",
+	"default_expire": "1h",
+	"expire_jitter": "10m",
+	"single_flight": {"type": "redis", "redis": {"key_prefix": "app:sf:", "wait_timeout": 500000000}},
+	"buckets": {
+		"local": {"cache_db": {"type": "memory"}, "codec": "msgpack", "expire": "1m"}
+	}
+}`, s.Addr())
+
+	var conf config.Config
+	require.NoError(t, json.Unmarshal([]byte(text), &conf))
+	require.Equal(t, time.Hour, conf.DefaultExpire.Duration())
+	require.Equal(t, time.Millisecond*500, conf.SingleFlight.Redis.WaitTimeout.Duration())
+
+	cache, err := zcache.NewCacheFromConfig(&conf)
+	require.NoError(t, err)
+	defer cache.Close()
+	loader := func(query zcache.IQuery) (interface{}, error) {
+		return "v" + query.ArgsText(), nil
+	}
+	cache.RegisterLoaderFn("test", loader)
+	cache.RegisterLoaderFn("local", loader)
+
+	var result string
+	require.NoError(t, cache.Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, "v1", result)
+	v, err := s.Get("app:test/1")
+	require.NoError(t, err)
+	require.Equal(t, `"v1"`, v)
+	ttl := s.TTL("app:test/1")
+	require.True(t, ttl >= time.Hour && ttl < time.Hour+time.Minute*10, ttl)
+
+	// bucket使用自己的缓存数据库
+	require.NoError(t, cache.Query("local", &result, zcache.QC().Args(1)))
+	require.Equal(t, "v1", result)
+	require.False(t, s.Exists("app:local/1"))
+}
+
+func TestConfigShareRedisClient(t *testing.T) {
+	s := miniredis.RunT(t)
+	text := fmt.Sprintf(`{
+	"cache_db": {"type": "redis", "redis": {"addrs": ["%[1]s"], "key_prefix": "app:"}},
+	"single_flight": {"type": "redis", "redis": {"addrs": ["%[1]s"]}},
+	"buckets": {
+		"shared": {"cache_db": {"type": "redis", "redis": {"addrs": ["%[1]s"], "key_prefix": "shared:"}}},
+		"db1": {"cache_db": {"type": "redis", "redis": {"addrs": ["%[1]s"], "db": 1, "key_prefix": "db1:"}}}
+	}
+}`, s.Addr())
+
+	var conf config.Config
+	require.NoError(t, json.Unmarshal([]byte(text), &conf))
+	cache, err := zcache.NewCacheFromConfig(&conf)
+	require.NoError(t, err)
+	loader := func(query zcache.IQuery) (interface{}, error) {
+		return "v" + query.ArgsText(), nil
+	}
+
+	var result string
+	for _, bucket := range []string{"test", "shared", "db1"} {
+		cache.RegisterLoaderFn(bucket, loader)
+		require.NoError(t, cache.Query(bucket, &result, zcache.QC().Args(1)))
+		require.Equal(t, "v1", result)
+	}
+	require.True(t, s.Exists("app:test:1"))
+	require.True(t, s.Exists("shared:shared:1"))
+	s.Select(1)
+	require.True(t, s.Exists("db1:db1:1"))
+	s.Select(0)
+
+	// 连接选项相同的缓存数据库和单跑模块共用一个客户端, 依次查询时每个客户端只会建立一个连接
+	require.Equal(t, 2, s.TotalConnectionCount())
+
+	// 共用的客户端只会关闭一次
+	require.NoError(t, cache.Close())
+	require.Eventually(t, func() bool {
+		return s.CurrentConnectionCount() == 0
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestConfigYaml(t *testing.T) {
+	text := `
+cache_db:
+  type: memory
+  memory:
+    cleanup_interval: 30s
+codec: json_iterator
+default_expire: 5m
+single_flight:
+  type: single
+  max_wait: 2s
+buckets:
+  user:
+    expire: 1m
+    expire_jitter: 10s
+    direct_return_on_cache_fault: false
+`
+	var conf config.Config
+	require.NoError(t, yaml.Unmarshal([]byte(text), &conf))
+	require.NoError(t, conf.Validate())
+	require.Equal(t, time.Second*30, conf.CacheDB.Memory.CleanupInterval.Duration())
+	require.Equal(t, time.Second*2, conf.SingleFlight.MaxWait.Duration())
+	require.Equal(t, time.Second*10, conf.Buckets["user"].ExpireJitter.Duration())
+	require.False(t, *conf.Buckets["user"].DirectReturnOnCacheFault)
+
+	cache, err := zcache.NewCacheFromConfig(&conf)
+	require.NoError(t, err)
+	require.NoError(t, cache.Close())
+}
+
+func TestConfigValidate(t *testing.T) {
+	conf := &config.Config{
+		CacheDB:      config.CacheDB{Type: "redis"},
+		Codec:        "unknown",
+		ExpireJitter: config.Duration(time.Minute),
+		SingleFlight: config.SingleFlight{Type: "single", ShardCount: 3},
+		Buckets: map[string]config.Bucket{
+			"a": {CacheDB: &config.CacheDB{Type: "mongo"}},
+			"b": {Codec: "yaml"},
+		},
+	}
+
+	// 一次返回所有的错误
+	_, err := zcache.NewCacheFromConfig(conf)
+	es, ok := zcache.DecodeErrors(err)
+	require.True(t, ok)
+	var texts []string
+	for _, e := range es.Errs() {
+		texts = append(texts, e.Error())
+	}
+	require.Equal(t, []string{
+		"cache_db.redis: is required when type is redis",
+		`codec: unknown codec "unknown"`,
+		"expire_jitter: requires a positive expire",
+		"single_flight.shard_count: must be power of 2",
+		`buckets.a.cache_db.type: unknown cache db type "mongo"`,
+		`buckets.b.codec: unknown codec "yaml"`,
+	}, texts)
+
+	// 单跑模块使用redis时必须有redis客户端
+	conf = &config.Config{SingleFlight: config.SingleFlight{Type: "redis"}}
+	require.EqualError(t, conf.Validate(), "single_flight.redis.addrs: is required when cache db is not redis")
+}
@@ -0,0 +1,158 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/core"
+	single_sf "github.com/zlyuancn/zcache/single_flight/single-sf"
+)
+
+type ctxKey struct{}
+
+func TestContextLoader(t *testing.T) {
+	cache := zcache.NewCache()
+	cache.RegisterContextLoaderFn("test", func(ctx context.Context, query core.IQuery) (interface{}, error) {
+		return ctx.Value(ctxKey{}), nil
+	})
+
+	// 上下文的值会传给加载器
+	ctx := context.WithValue(context.Background(), ctxKey{}, "v1")
+	var result string
+	require.NoError(t, cache.QueryWithContext(ctx, "test", &result, zcache.QC().Args(1)))
+	require.Equal(t, "v1", result)
+
+	// 已经结束的上下文直接返回
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Equal(t, context.Canceled, cache.QueryWithContext(ctx, "test", &result, zcache.QC().Args(2)))
+}
+
+func TestContextCancelLoader(t *testing.T) {
+	cache := zcache.NewCache()
+
+	canceled := make(chan error, 1)
+	cache.RegisterContextLoaderFn("test", func(ctx context.Context, query core.IQuery) (interface{}, error) {
+		<-ctx.Done()
+		canceled <- ctx.Err()
+		return nil, ctx.Err()
+	})
+
+	// 上下文超时后加载器会收到取消信号
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	var result string
+	require.Error(t, cache.QueryWithContext(ctx, "test", &result, zcache.QC().Args(1)))
+	select {
+	case err := <-canceled:
+		require.Equal(t, context.DeadlineExceeded, err)
+	case <-time.After(time.Second):
+		t.Fatal("loader was not canceled")
+	}
+}
+
+func TestContextCancelBatchLoader(t *testing.T) {
+	cache := zcache.NewCache()
+
+	canceled := make(chan error, 1)
+	cache.RegisterContextBatchLoaderFn("test", func(ctx context.Context, queries []core.IQuery) (map[int]interface{}, error) {
+		<-ctx.Done()
+		canceled <- ctx.Err()
+		return nil, ctx.Err()
+	})
+
+	// 批量加载时上下文超时后加载器也会收到取消信号
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	var results []string
+	require.Error(t, cache.MQueryWithContext(ctx, "test", &results, zcache.QC().Args(1), zcache.QC().Args(2)))
+	select {
+	case err := <-canceled:
+		require.Equal(t, context.DeadlineExceeded, err)
+	case <-time.After(time.Second):
+		t.Fatal("batch loader was not canceled")
+	}
+}
+
+func TestContextLoaderSharedWait(t *testing.T) {
+	sf := single_sf.NewSingleFlight()
+	cache := zcache.NewCache(zcache.WithSingleFlight(sf))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	loaded := make(chan error, 1)
+	cache.RegisterContextLoaderFn("test", func(ctx context.Context, query core.IQuery) (interface{}, error) {
+		close(started)
+		<-release
+		loaded <- ctx.Err()
+		return "v", nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		var result string
+		leaderErr <- cache.QueryWithContext(ctx, "test", &result, zcache.QC().Args(1))
+	}()
+	<-started
+
+	type queryResult struct {
+		v   string
+		err error
+	}
+	waiter := make(chan queryResult, 1)
+	go func() {
+		var result string
+		err := cache.Query("test", &result, zcache.QC().Args(1))
+		waiter <- queryResult{result, err}
+	}()
+	waitFor(t, sf, 1)
+
+	// 第一个调用者取消后, 还有调用者在等待时加载器会继续执行
+	cancel()
+	require.Equal(t, context.Canceled, <-leaderErr)
+	close(release)
+	require.NoError(t, <-loaded)
+	r := <-waiter
+	require.NoError(t, r.err)
+	require.Equal(t, "v", r.v)
+}
+
+func TestContextSingleFlightWait(t *testing.T) {
+	cache := zcache.NewCache()
+
+	release := make(chan struct{})
+	cache.RegisterLoaderFn("test", func(query core.IQuery) (interface{}, error) {
+		<-release
+		return "v", nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		var result string
+		done <- cache.Query("test", &result, zcache.QC().Args(1))
+	}()
+	time.Sleep(time.Millisecond * 20)
+
+	// 等待其它调用者的结果时上下文超时会立即返回
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	var result string
+	start := time.Now()
+	require.Equal(t, context.DeadlineExceeded, cache.QueryWithContext(ctx, "test", &result, zcache.QC().Args(1)))
+	require.Less(t, int64(time.Since(start)), int64(time.Second))
+
+	close(release)
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,70 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/envelope"
+)
+
+func TestEarlyRefresh(t *testing.T) {
+	cache := zcache.NewCache(zcache.WithEarlyRefresh(1))
+	const bucket = "test"
+
+	var count int32
+	cache.RegisterLoaderFn(bucket, func(query zcache.IQuery) (interface{}, error) {
+		n := atomic.AddInt32(&count, 1)
+		time.Sleep(time.Millisecond * 20)
+		return strconv.Itoa(int(n)), nil
+	}, zcache.WithLoaderExpire(time.Second), zcache.WithLoaderEarlyRefresh(1000))
+
+	var result string
+	require.NoError(t, cache.Query(bucket, &result))
+	require.Equal(t, "1", result)
+
+	// 加载耗时 * 系数 远大于剩余有效时间, 读取时返回旧数据并在后台刷新
+	require.NoError(t, cache.Query(bucket, &result))
+	require.Equal(t, "1", result)
+	require.Eventually(t, func() bool {
+		_ = cache.Query(bucket, &result)
+		return result != "1"
+	}, time.Second, time.Millisecond*5)
+}
+
+func TestEarlyRefreshDisabled(t *testing.T) {
+	cache := zcache.NewCache(zcache.WithEarlyRefresh(1000))
+	const bucket = "test"
+
+	var count int32
+	cache.RegisterLoaderFn(bucket, func(query zcache.IQuery) (interface{}, error) {
+		atomic.AddInt32(&count, 1)
+		time.Sleep(time.Millisecond * 20)
+		return "v", nil
+	}, zcache.WithLoaderExpire(time.Second), zcache.WithLoaderEarlyRefresh(-1))
+
+	// 加载器关闭了提前刷新
+	var result string
+	for i := 0; i < 10; i++ {
+		require.NoError(t, cache.Query(bucket, &result))
+	}
+	time.Sleep(time.Millisecond * 50)
+	require.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
+
+func TestEnvelopeEarlyRefreshFields(t *testing.T) {
+	e := &envelope.Envelope{FreshUntil: time.Now().UnixNano(), LoadDuration: int64(time.Millisecond * 20), Data: []byte("v")}
+	require.Equal(t, e, envelope.Decode(envelope.Encode(e)))
+}
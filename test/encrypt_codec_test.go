@@ -0,0 +1,125 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	memory_cache "github.com/zlyuancn/zcache/cachedb/memory-cache"
+	"github.com/zlyuancn/zcache/codec"
+	"github.com/zlyuancn/zcache/envelope"
+)
+
+var (
+	encryptKey1 = codec.EncryptKey{Id: 1, Key: bytes.Repeat([]byte{1}, 32)}
+	encryptKey2 = codec.EncryptKey{Id: 2, Key: bytes.Repeat([]byte{2}, 16)}
+)
+
+func TestEncryptCodec(t *testing.T) {
+	c := codec.NewEncryptCodec(codec.Json, encryptKey1)
+	require.Equal(t, codec.EncryptIdOffset+codec.JsonId, codec.IdOf(c))
+
+	bs, err := c.Encode("secret")
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(bs, []byte("secret")))
+	var s string
+	require.NoError(t, c.Decode(bs, &s))
+	require.Equal(t, "secret", s)
+
+	// 相同数据每次加密的结果不同
+	bs2, err := c.Encode("secret")
+	require.NoError(t, err)
+	require.NotEqual(t, bs, bs2)
+
+	// 轮换密钥后可以解密旧数据, 新数据使用新密钥加密
+	rotated := codec.NewEncryptCodec(codec.Json, encryptKey2, encryptKey1)
+	s = ""
+	require.NoError(t, rotated.Decode(bs, &s))
+	require.Equal(t, "secret", s)
+	bs2, err = rotated.Encode("secret")
+	require.NoError(t, err)
+	require.True(t, errors.Is(c.Decode(bs2, &s), codec.ErrUnknownKey))
+
+	// 篡改的数据
+	for _, i := range []int{0, 2, len(bs) / 2, len(bs) - 1} {
+		tampered := append([]byte(nil), bs...)
+		tampered[i] ^= 1
+		err = c.Decode(tampered, &s)
+		require.True(t, errors.Is(err, codec.ErrTampered) || errors.Is(err, codec.ErrUnknownKey), err)
+	}
+	require.Equal(t, codec.ErrTampered, c.Decode(bs[:10], &s))
+
+	// 密钥错误
+	require.Panics(t, func() { codec.NewEncryptCodec(codec.Json, codec.EncryptKey{Id: 1, Key: []byte("short")}) })
+	require.Panics(t, func() { codec.NewEncryptCodec(codec.Json, encryptKey1, encryptKey1) })
+}
+
+func TestEncryptCodecCache(t *testing.T) {
+	db := memory_cache.NewMemoryCache()
+	c := codec.NewEncryptCodec(codec.NewCompressCodec(codec.MsgPack, codec.WithCompressThreshold(0)), encryptKey1)
+	cache := zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(c), zcache.WithEnvelope())
+
+	data := &codecTestData{A: "phone-number", B: 1}
+	require.NoError(t, cache.Save("test", data, 0, zcache.QC().Args(1)))
+	raw, err := db.Get(zcache.Q("test", zcache.QC().Args(1)))
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(raw, []byte("phone-number")))
+
+	var result codecTestData
+	require.NoError(t, cache.Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, *data, result)
+
+	// 篡改缓存中的数据
+	raw[len(raw)-1] ^= 1
+	require.NoError(t, db.Set(zcache.Q("test", zcache.QC().Args(1)), raw, 0))
+	err = cache.Query("test", &result, zcache.QC().Args(1))
+	require.True(t, errors.Is(err, codec.ErrTampered), err)
+}
+
+func TestEncryptCodecRejectPlaintext(t *testing.T) {
+	db := memory_cache.NewMemoryCache()
+	c := codec.NewEncryptCodec(codec.MsgPack, encryptKey1)
+	cache := zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(c), zcache.WithFallbackCodecs(codec.MsgPack), zcache.WithEnvelope())
+
+	// 伪造的明文数据, 带有内置编解码器的id
+	plain, err := codec.MsgPack.Encode(&codecTestData{A: "forged"})
+	require.NoError(t, err)
+	bs := envelope.Encode(&envelope.Envelope{CodecId: codec.MsgPackId, Data: plain})
+	require.NoError(t, db.Set(zcache.Q("test", zcache.QC().Args(1)), bs, 0))
+	var result codecTestData
+	require.Error(t, cache.Query("test", &result, zcache.QC().Args(1)))
+	require.Empty(t, result.A)
+
+	// 没有编解码器id的明文数据不会使用备用编解码器解码
+	require.NoError(t, db.Set(zcache.Q("test", zcache.QC().Args(2)), plain, 0))
+	require.Error(t, cache.Query("test", &result, zcache.QC().Args(2)))
+	require.Empty(t, result.A)
+}
+
+func TestEncryptCodecPerCache(t *testing.T) {
+	db1, db2 := memory_cache.NewMemoryCache(), memory_cache.NewMemoryCache()
+	cache1 := zcache.NewCache(zcache.WithCacheDB(db1), zcache.WithCodec(codec.NewEncryptCodec(codec.Json, encryptKey1)), zcache.WithEnvelope())
+	require.NoError(t, cache1.Save("test", &codecTestData{A: "a"}, 0))
+
+	// 相同id但密钥不同的编解码器不会替换其它缓存的编解码器
+	cache2 := zcache.NewCache(zcache.WithCacheDB(db2), zcache.WithCodec(codec.NewEncryptCodec(codec.Json, encryptKey2)), zcache.WithEnvelope())
+	require.NoError(t, cache2.Save("test", &codecTestData{A: "b"}, 0))
+
+	var result codecTestData
+	require.NoError(t, cache1.Query("test", &result))
+	require.Equal(t, "a", result.A)
+	require.NoError(t, cache2.Query("test", &result))
+	require.Equal(t, "b", result.A)
+	require.Nil(t, codec.GetCodec(codec.EncryptIdOffset+codec.JsonId))
+}
@@ -0,0 +1,107 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	memory_cache "github.com/zlyuancn/zcache/cachedb/memory-cache"
+	"github.com/zlyuancn/zcache/codec"
+	"github.com/zlyuancn/zcache/envelope"
+	"github.com/zlyuancn/zcache/errs"
+)
+
+func TestEnvelope(t *testing.T) {
+	cache := zcache.NewCache(zcache.WithEnvelope())
+
+	cache.RegisterLoaderFn("test", func(query zcache.IQuery) (interface{}, error) {
+		time.Sleep(time.Millisecond * 10)
+		return "v", nil
+	}, zcache.WithLoaderExpire(time.Minute))
+
+	start := time.Now().UnixNano()
+	var result string
+	require.NoError(t, cache.Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, "v", result)
+
+	// 加载的数据带有元数据
+	e, err := cache.GetEnvelope(zcache.Q("test", zcache.QC().Args(1)))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, e.CreatedAt, start)
+	require.GreaterOrEqual(t, e.LoadDuration, int64(time.Millisecond*10))
+	require.Equal(t, e.CreatedAt+int64(time.Minute), e.FreshUntil)
+	require.Zero(t, e.SoftExpireAt)
+
+	// 写入的数据没有加载耗时, 永不过期的数据没有过期时间
+	require.NoError(t, cache.Save("test", "v2", -1, zcache.QC().Args(2)))
+	e, err = cache.GetEnvelope(zcache.Q("test", zcache.QC().Args(2)))
+	require.NoError(t, err)
+	require.NotZero(t, e.CreatedAt)
+	require.Zero(t, e.LoadDuration)
+	require.Zero(t, e.FreshUntil)
+
+	_, err = cache.GetEnvelope(zcache.Q("test", zcache.QC().Args(3)))
+	require.Equal(t, errs.CacheMiss, err)
+}
+
+func TestEnvelopeCompatible(t *testing.T) {
+	db := memory_cache.NewMemoryCache()
+	plain := zcache.NewCache(zcache.WithCacheDB(db))
+	enveloped := zcache.NewCache(zcache.WithCacheDB(db), zcache.WithEnvelope())
+
+	// 开启信封后可以读取旧数据
+	require.NoError(t, plain.Save("test", "v1", 0, zcache.QC().Args(1)))
+	var result string
+	require.NoError(t, enveloped.Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, "v1", result)
+	e, err := enveloped.GetEnvelope(zcache.Q("test", zcache.QC().Args(1)))
+	require.NoError(t, err)
+	require.Zero(t, e.CreatedAt)
+
+	// 关闭信封后可以读取信封格式的数据
+	require.NoError(t, enveloped.Save("test", "v2", 0, zcache.QC().Args(2)))
+	bs, err := db.Get(zcache.Q("test", zcache.QC().Args(2)))
+	require.NoError(t, err)
+	require.True(t, envelope.IsEnvelope(bs))
+	require.NoError(t, plain.Query("test", &result, zcache.QC().Args(2)))
+	require.Equal(t, "v2", result)
+}
+
+func TestEnvelopeLegacyData(t *testing.T) {
+	db := memory_cache.NewMemoryCache()
+	plain := zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(codec.Byte))
+	enveloped := zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(codec.Byte), zcache.WithEnvelope())
+
+	// 以魔数开头的旧数据不会被当作信封
+	legacy := append([]byte{0x00, 'z', 'c', envelope.Version, 2, 3, 2}, "data"...)
+	require.False(t, envelope.IsEnvelope(legacy))
+	require.Equal(t, legacy, envelope.Decode(legacy).Data)
+	require.NoError(t, plain.Save("test", legacy, 0, zcache.QC().Args(1)))
+	for _, cache := range []*zcache.Cache{plain, enveloped} {
+		var result []byte
+		require.NoError(t, cache.Query("test", &result, zcache.QC().Args(1)))
+		require.Equal(t, legacy, result)
+	}
+
+	// 启用信封后写入的以魔数开头的数据也可以正确读取
+	require.NoError(t, enveloped.Save("test", legacy, 0, zcache.QC().Args(2)))
+	var result []byte
+	require.NoError(t, enveloped.Query("test", &result, zcache.QC().Args(2)))
+	require.Equal(t, legacy, result)
+
+	// 被修改的信封视为普通数据
+	bs := envelope.Encode(&envelope.Envelope{CreatedAt: 1, Data: []byte("v")})
+	require.True(t, envelope.IsEnvelope(bs))
+	bs[len(bs)-2] ^= 1
+	require.False(t, envelope.IsEnvelope(bs))
+}
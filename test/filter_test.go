@@ -0,0 +1,219 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	rredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/errs"
+	memory_bloom "github.com/zlyuancn/zcache/filter/memory-bloom"
+	redis_bloom "github.com/zlyuancn/zcache/filter/redis-bloom"
+)
+
+func TestFilter(t *testing.T) {
+	f := memory_bloom.NewMemoryBloom(memory_bloom.WithCapacity(1000))
+	cache := zcache.NewCache(zcache.WithFilter("test", f))
+	defer cache.Close()
+
+	var calls int32
+	cache.RegisterLoaderFn("test", func(query zcache.IQuery) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return "v" + query.ArgsText(), nil
+	})
+	require.NoError(t, cache.AddToFilter("test", 1, 2))
+
+	// 不在过滤器中的数据不会调用加载器
+	var result string
+	require.Equal(t, errs.NotFound, cache.Query("test", &result, zcache.QC().Args(3)))
+	require.Equal(t, int32(0), atomic.LoadInt32(&calls))
+	require.NoError(t, cache.Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, "v1", result)
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 批量获取
+	qcs := []*zcache.QueryConfig{zcache.QC().Args(1), zcache.QC().Args(3), zcache.QC().Args(2), zcache.QC().Args(3)}
+	var results []string
+	require.Error(t, cache.MQuery("test", &results, qcs...))
+	require.Equal(t, []string{"v1", "", "v2", ""}, results)
+	require.NoError(t, qcs[0].GetErr())
+	require.Equal(t, errs.NotFound, qcs[1].GetErr())
+	require.NoError(t, qcs[2].GetErr())
+	require.Equal(t, errs.NotFound, qcs[3].GetErr())
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// 全部不存在
+	results = nil
+	require.Error(t, cache.MQuery("test", &results, zcache.QC().Args(4), zcache.QC().Args(5)))
+	require.Equal(t, []string{"", ""}, results)
+
+	// 写入缓存的数据会添加到过滤器
+	require.NoError(t, cache.Save("test", "v3", 0, zcache.QC().Args(3)))
+	require.NoError(t, cache.Query("test", &result, zcache.QC().Args(3)))
+	require.Equal(t, "v3", result)
+
+	// 没有过滤器的bucket不受影响
+	cache.RegisterLoaderFn("other", func(query zcache.IQuery) (interface{}, error) {
+		return "v", nil
+	})
+	require.NoError(t, cache.Query("other", &result, zcache.QC().Args(3)))
+}
+
+func TestMemoryBloomSeed(t *testing.T) {
+	var version int32
+	release := make(chan struct{})
+	f := memory_bloom.NewMemoryBloom(memory_bloom.WithSeed(func(ctx context.Context, add func(keys ...string) error) error {
+		if atomic.AddInt32(&version, 1) == 1 {
+			<-release
+			return add("1", "2")
+		}
+		return add("2", "3")
+	}, time.Millisecond*50))
+	defer f.Close()
+
+	// 第一次填充完成前所有数据都视为可能存在
+	exists, err := f.MayExist(context.Background(), "1", "3")
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, true}, exists)
+	close(release)
+
+	time.Sleep(time.Millisecond * 20)
+	exists, err = f.MayExist(context.Background(), "1", "2", "3")
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, true, false}, exists)
+
+	// 重建后使用新的数据
+	time.Sleep(time.Millisecond * 80)
+	exists, err = f.MayExist(context.Background(), "1", "2", "3")
+	require.NoError(t, err)
+	require.Equal(t, []bool{false, true, true}, exists)
+}
+
+func TestRedisBloom(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := rredis.NewClient(&rredis.Options{Addr: s.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	f := redis_bloom.NewRedisBloom(client, "test:bloom", redis_bloom.WithCapacity(1000))
+	defer f.Close()
+
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	require.NoError(t, f.Add(ctx, keys...))
+	exists, err := f.MayExist(ctx, append(keys, "a", "b")...)
+	require.NoError(t, err)
+	for i := range keys {
+		require.True(t, exists[i])
+	}
+	require.False(t, exists[100] && exists[101])
+
+	// 重建期间添加的数据不会丢失
+	err = f.Rebuild(ctx, func(ctx context.Context, add func(keys ...string) error) error {
+		if err := add("x"); err != nil {
+			return err
+		}
+		return f.Add(ctx, "y")
+	})
+	require.NoError(t, err)
+	exists, err = f.MayExist(ctx, "x", "y", "0")
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, true, false}, exists)
+	require.False(t, s.Exists("test:bloom:rebuilding"))
+
+	// 其它实例共享同一个过滤器, 已经存在的key不会重新填充
+	var seeded int32
+	f2 := redis_bloom.NewRedisBloom(client, "test:bloom", redis_bloom.WithCapacity(1000),
+		redis_bloom.WithSeed(func(ctx context.Context, add func(keys ...string) error) error {
+			atomic.AddInt32(&seeded, 1)
+			return nil
+		}))
+	defer f2.Close()
+	time.Sleep(time.Millisecond * 50)
+	exists, err = f2.MayExist(ctx, "x", "0")
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, false}, exists)
+	require.Equal(t, int32(0), atomic.LoadInt32(&seeded))
+}
+
+func TestRedisBloomConcurrentInit(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := rredis.NewClient(&rredis.Options{Addr: s.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	var seeded int32
+	started, release := make(chan struct{}), make(chan struct{})
+	seed := func(ctx context.Context, add func(keys ...string) error) error {
+		if atomic.AddInt32(&seeded, 1) == 1 {
+			close(started)
+		}
+		<-release
+		for i := 0; i < len(keys); i += 10 {
+			if err := add(keys[i : i+10]...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// 两个实例同时启动, 只有一个实例填充, 另一个等待填充完成
+	f1 := redis_bloom.NewRedisBloom(client, "test:bloom", redis_bloom.WithCapacity(1000), redis_bloom.WithSeed(seed))
+	defer f1.Close()
+	f2 := redis_bloom.NewRedisBloom(client, "test:bloom", redis_bloom.WithCapacity(1000), redis_bloom.WithSeed(seed))
+	defer f2.Close()
+	<-started
+	close(release)
+
+	for _, f := range []*redis_bloom.RedisBloom{f1, f2} {
+		require.Eventually(t, func() bool {
+			exists, err := f.MayExist(ctx, "a")
+			return err == nil && !exists[0]
+		}, time.Second, time.Millisecond*10)
+		exists, err := f.MayExist(ctx, keys...)
+		require.NoError(t, err)
+		for i := range keys {
+			require.True(t, exists[i], keys[i])
+		}
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&seeded))
+	require.False(t, s.Exists("test:bloom:rebuilding"))
+}
+
+func TestFilterErrorFailOpen(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := rredis.NewClient(&rredis.Options{Addr: s.Addr()})
+	defer client.Close()
+
+	f := redis_bloom.NewRedisBloom(client, "test:bloom")
+	cache := zcache.NewCache(zcache.WithFilter("test", f))
+	cache.RegisterLoaderFn("test", func(query zcache.IQuery) (interface{}, error) {
+		return "v", nil
+	})
+
+	// 过滤器出错时视为数据可能存在
+	s.Close()
+	var result string
+	require.NoError(t, cache.Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, "v", result)
+}
@@ -0,0 +1,105 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/codec"
+	"github.com/zlyuancn/zcache/core"
+)
+
+func TestInterceptors(t *testing.T) {
+	var mx sync.Mutex
+	var records []string
+	record := func(name string) core.Interceptor {
+		return func(ctx context.Context, inv *core.Invocation, next core.Handler) error {
+			mx.Lock()
+			records = append(records, fmt.Sprintf("%s>%s", name, inv.Op))
+			mx.Unlock()
+			err := next(ctx, inv)
+			mx.Lock()
+			records = append(records, fmt.Sprintf("%s<%s:%d", name, inv.Op, len(inv.Data)))
+			mx.Unlock()
+			return err
+		}
+	}
+	takeRecords := func() string {
+		mx.Lock()
+		defer mx.Unlock()
+		s := strings.Join(records, " ")
+		records = nil
+		return s
+	}
+
+	cache := zcache.NewCache(
+		zcache.WithCodec(codec.Byte),
+		zcache.WithInterceptors(record("a"), record("b")),
+	)
+	cache.RegisterLoaderFn("test", func(query core.IQuery) (interface{}, error) {
+		return []byte("v" + query.ArgsText()), nil
+	})
+
+	var result []byte
+	require.NoError(t, cache.Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, "a>get b>get a>load b>load b<load:1 a<load:1 b<get:1 a<get:1", takeRecords())
+	require.NoError(t, cache.Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, "a>get b>get b<get:1 a<get:1", takeRecords())
+
+	var results [][]byte
+	require.NoError(t, cache.MQuery("test", &results, zcache.QC().Args(1), zcache.QC().Args(1)))
+	require.Equal(t, "a>mquery b>mquery b<mquery:2 a<mquery:2", takeRecords())
+
+	require.NoError(t, cache.Save("test", []byte("x"), 0, zcache.QC().Args(2)))
+	require.Equal(t, "a>set b>set b<set:1 a<set:1", takeRecords())
+	require.NoError(t, cache.Del("test", zcache.QC().Args(2)))
+	require.Equal(t, "a>del b>del b<del:0 a<del:0", takeRecords())
+	require.NoError(t, cache.DelBucket("test"))
+	require.Equal(t, "a>del_bucket b>del_bucket b<del_bucket:0 a<del_bucket:0", takeRecords())
+}
+
+func TestInterceptorModify(t *testing.T) {
+	fault := errors.New("fault")
+	cache := zcache.NewCache(
+		zcache.WithCodec(codec.Byte),
+		zcache.WithInterceptors(func(ctx context.Context, inv *core.Invocation, next core.Handler) error {
+			// 注入故障
+			if inv.Op == core.OpGet && inv.Queries[0].ArgsText() == "fault" {
+				return fault
+			}
+			// 修改写入的数据
+			if inv.Op == core.OpSet {
+				for i, bs := range inv.Data {
+					inv.Data[i] = append([]byte("prefix:"), bs...)
+				}
+			}
+			return next(ctx, inv)
+		}),
+	)
+
+	var result []byte
+	require.Equal(t, fault, cache.Query("test", &result, zcache.QC().Args("fault")))
+
+	require.NoError(t, cache.Save("test", []byte("v"), 0, zcache.QC().Args(1)))
+	require.NoError(t, cache.Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, "prefix:v", string(result))
+
+	// 批量写入
+	require.NoError(t, cache.MSave("test", map[int][]byte{2: []byte("v2")}, 0))
+	require.NoError(t, cache.Query("test", &result, zcache.QC().Args(2)))
+	require.Equal(t, "prefix:v2", string(result))
+}
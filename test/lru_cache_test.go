@@ -0,0 +1,87 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	lru_cache "github.com/zlyuancn/zcache/cachedb/lru-cache"
+	"github.com/zlyuancn/zcache/errs"
+)
+
+func TestLruCacheEvict(t *testing.T) {
+	db := lru_cache.NewLruCache(
+		lru_cache.WithMaxBytes(30),
+		lru_cache.WithBucketMaxBytes("small", 10),
+	)
+	defer db.Close()
+
+	value := []byte("0123456789") // 10 字节
+	q1 := zcache.Q("test", zcache.QC().Args(1))
+	q2 := zcache.Q("test", zcache.QC().Args(2))
+	q3 := zcache.Q("test", zcache.QC().Args(3))
+	q4 := zcache.Q("test", zcache.QC().Args(4))
+
+	require.NoError(t, db.Set(q1, value, 0))
+	require.NoError(t, db.Set(q2, value, 0))
+	require.NoError(t, db.Set(q3, value, 0))
+
+	// 访问 q1 后 q2 成为最久未使用的数据
+	_, err := db.Get(q1)
+	require.NoError(t, err)
+
+	require.NoError(t, db.Set(q4, value, 0))
+	_, err = db.Get(q2)
+	require.Equal(t, errs.CacheMiss, err)
+	for _, q := range []zcache.IQuery{q1, q3, q4} {
+		_, err = db.Get(q)
+		require.NoError(t, err)
+	}
+
+	stats := db.Stats()
+	require.Equal(t, 3, stats.Count)
+	require.Equal(t, int64(30), stats.Bytes)
+	require.Equal(t, uint64(1), stats.Evictions)
+
+	// bucket 限制
+	s1 := zcache.Q("small", zcache.QC().Args(1))
+	s2 := zcache.Q("small", zcache.QC().Args(2))
+	require.NoError(t, db.Set(s1, value, 0))
+	require.NoError(t, db.Set(s2, value, 0))
+	_, err = db.Get(s1)
+	require.Equal(t, errs.CacheMiss, err)
+	_, err = db.Get(s2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), db.BucketStats("small").Evictions)
+	require.Equal(t, int64(30), db.Stats().Bytes)
+
+	// 超过容量的数据不会被写入
+	require.NoError(t, db.Set(s1, []byte("01234567890"), 0))
+	_, err = db.Get(s1)
+	require.Equal(t, errs.CacheMiss, err)
+	require.Equal(t, uint64(1), db.BucketStats("small").Rejected)
+
+	testEvictions := db.BucketStats("test").Evictions
+	require.NoError(t, db.DelBucket("test"))
+	require.Equal(t, int64(10), db.Stats().Bytes)
+	require.Equal(t, 1, db.Stats().Count)
+
+	// 删除bucket后保留计数
+	require.NoError(t, db.DelBucket("small"))
+	stats = db.BucketStats("small")
+	require.Equal(t, 0, stats.Count)
+	require.Equal(t, int64(0), stats.Bytes)
+	require.Equal(t, uint64(1), stats.Evictions)
+	require.Equal(t, uint64(1), stats.Rejected)
+	require.Equal(t, testEvictions, db.BucketStats("test").Evictions)
+	require.NotZero(t, testEvictions)
+}
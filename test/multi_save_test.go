@@ -0,0 +1,78 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	rredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	redis_cache "github.com/zlyuancn/zcache/cachedb/redis-cache"
+	"github.com/zlyuancn/zcache/codec"
+)
+
+func testCacheMSave(t *testing.T, cache *zcache.Cache) {
+	const bucket = "test"
+
+	// 切片
+	qcs := []*zcache.QueryConfig{zcache.QC().Args(1), zcache.QC().Args(2), zcache.QC().Args(3)}
+	require.NoError(t, cache.MSave(bucket, []string{"v1", "v2", "v3"}, 0, qcs...))
+	var results []string
+	require.NoError(t, cache.MQuery(bucket, &results, qcs...))
+	require.Equal(t, []string{"v1", "v2", "v3"}, results)
+
+	// map
+	require.NoError(t, cache.MSave(bucket, map[int]string{4: "v4", 5: "v5"}, 0))
+	results = nil
+	require.NoError(t, cache.MQuery(bucket, &results, zcache.QC().Args(4), zcache.QC().Args(5)))
+	require.Equal(t, []string{"v4", "v5"}, results)
+
+	// 每条数据的错误
+	qcs = []*zcache.QueryConfig{zcache.QC().Args(6), zcache.QC().Args(7)}
+	err := cache.MSave(bucket, []interface{}{"v6", 7}, 0, qcs...)
+	require.Error(t, err)
+	es, ok := zcache.DecodeErrors(err)
+	require.True(t, ok)
+	require.Len(t, es.Errs(), 2)
+	require.NoError(t, es.Errs()[0])
+	require.Error(t, es.Errs()[1])
+	require.NoError(t, qcs[0].GetErr())
+	require.Error(t, qcs[1].GetErr())
+
+	// 过期时间
+	require.NoError(t, cache.MSave(bucket, []string{"v8"}, time.Millisecond*100, zcache.QC().Args(8)))
+	var result string
+	require.NoError(t, cache.Query(bucket, &result, zcache.QC().Args(8)))
+	require.Equal(t, "v8", result)
+}
+
+func TestMemoryCacheMSave(t *testing.T) {
+	testCacheMSave(t, makeMemoryCache())
+	testCacheMSave(t, makeLruCache())
+	testCacheMSave(t, makeTwoLevelCache())
+}
+
+func TestRedisCacheMSave(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := rredis.NewClient(&rredis.Options{Addr: s.Addr()})
+	cache := zcache.NewCache(
+		zcache.WithCacheDB(redis_cache.NewRedisCache(client)),
+		zcache.WithCodec(codec.Byte),
+	)
+	testCacheMSave(t, cache)
+
+	// 写入使用管道一次完成, 并且设置了过期时间
+	require.True(t, s.Exists("test:8"))
+	require.Equal(t, time.Millisecond*100, s.TTL("test:8"))
+	require.Equal(t, time.Duration(0), s.TTL("test:1"))
+}
@@ -0,0 +1,147 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/errs"
+)
+
+func TestNotFound(t *testing.T) {
+	cache := zcache.NewCache(zcache.WithNotFoundExpire(time.Millisecond * 100))
+
+	var calls int32
+	cache.RegisterLoaderFn("test", func(query zcache.IQuery) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		if query.ArgsText() == "404" {
+			return nil, fmt.Errorf("user %s: %w", query.ArgsText(), zcache.NotFound)
+		}
+		return "v" + query.ArgsText(), nil
+	})
+
+	// 加载器返回的 NotFound 可以被包装, 返回给调用者的始终是 errs.NotFound
+	var result string
+	qc := zcache.QC().Args(404)
+	err := cache.Query("test", &result, qc)
+	require.Equal(t, errs.NotFound, err)
+	require.True(t, errors.Is(qc.GetErr(), zcache.NotFound))
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 数据不存在标记已经写入缓存
+	require.Equal(t, errs.NotFound, cache.Query("test", &result, zcache.QC().Args(404)))
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 标记过期后重新加载
+	time.Sleep(time.Millisecond * 150)
+	require.Equal(t, errs.NotFound, cache.Query("test", &result, zcache.QC().Args(404)))
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// 批量获取时只有不存在的数据返回 errs.NotFound
+	qcs := []*zcache.QueryConfig{zcache.QC().Args(1), zcache.QC().Args(404), zcache.QC().Args(2)}
+	var results []string
+	err = cache.MQuery("test", &results, qcs...)
+	require.Error(t, err)
+	require.Equal(t, []string{"v1", "", "v2"}, results)
+	require.NoError(t, qcs[0].GetErr())
+	require.Equal(t, errs.NotFound, qcs[1].GetErr())
+	require.NoError(t, qcs[2].GetErr())
+	require.Equal(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+func TestNotFoundLoaderExpire(t *testing.T) {
+	cache := zcache.NewCache()
+
+	var calls int32
+	cache.RegisterLoaderFn("test", func(query zcache.IQuery) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, zcache.NotFound
+	}, zcache.WithLoaderNotFoundExpire(-1))
+
+	// 加载器关闭了数据不存在标记的缓存
+	var result string
+	require.Equal(t, errs.NotFound, cache.Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, errs.NotFound, cache.Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestNotFoundBatchLoader(t *testing.T) {
+	cache := zcache.NewCache()
+
+	var calls int32
+	cache.RegisterBatchLoaderFn("test", func(queries []zcache.IQuery) (map[int]interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		results := make(map[int]interface{}, len(queries))
+		for i, q := range queries {
+			if q.ArgsText() == "404" {
+				results[i] = zcache.NotFound
+				continue
+			}
+			results[i] = "v" + q.ArgsText()
+		}
+		return results, nil
+	})
+
+	qcs := []*zcache.QueryConfig{zcache.QC().Args(1), zcache.QC().Args(404)}
+	for i := 0; i < 2; i++ {
+		var results []string
+		require.Error(t, cache.MQuery("test", &results, qcs...))
+		require.Equal(t, []string{"v1", ""}, results)
+		require.NoError(t, qcs[0].GetErr())
+		require.Equal(t, errs.NotFound, qcs[1].GetErr())
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 单条获取也会命中数据不存在标记
+	var result string
+	require.Equal(t, errs.NotFound, cache.Query("test", &result, zcache.QC().Args(404)))
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestNotFoundBatchLoaderSingleQuery(t *testing.T) {
+	cache := zcache.NewCache()
+
+	var calls int32
+	cache.RegisterBatchLoaderFn("test", func(queries []zcache.IQuery) (map[int]interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		results := make(map[int]interface{}, len(queries))
+		for i, q := range queries {
+			switch q.ArgsText() {
+			case "404":
+				results[i] = fmt.Errorf("user %s: %w", q.ArgsText(), zcache.NotFound)
+			case "500":
+				results[i] = errors.New("db error")
+			default:
+				results[i] = "v" + q.ArgsText()
+			}
+		}
+		return results, nil
+	})
+
+	// 缓存为空时单条获取也会使用批量加载器的结果中的错误
+	var result string
+	require.Equal(t, errs.NotFound, cache.Query("test", &result, zcache.QC().Args(404)))
+	require.Equal(t, errs.NotFound, cache.Query("test", &result, zcache.QC().Args(404)))
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 其它错误不会写入缓存
+	require.Error(t, cache.Query("test", &result, zcache.QC().Args(500)))
+	require.Error(t, cache.Query("test", &result, zcache.QC().Args(500)))
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	require.NoError(t, cache.Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, "v1", result)
+}
@@ -0,0 +1,169 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	rredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/cachedb"
+	redis_cache "github.com/zlyuancn/zcache/cachedb/redis-cache"
+	"github.com/zlyuancn/zcache/codec"
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/errs"
+)
+
+func makeRedisHashCache(t *testing.T, opts ...redis_cache.Option) (*miniredis.Miniredis, *zcache.Cache) {
+	s := miniredis.RunT(t)
+	client := rredis.NewClient(&rredis.Options{Addr: s.Addr()})
+	cache := zcache.NewCache(
+		zcache.WithCacheDB(redis_cache.NewRedisCache(client, opts...)),
+		zcache.WithCodec(codec.Byte),
+	)
+	return s, cache
+}
+
+func TestRedisHashCache(t *testing.T) {
+	s, cache := makeRedisHashCache(t, redis_cache.WithHashBuckets())
+	testCacheSet(t, cache)
+	testCacheDel(t, cache)
+	testCacheDelBucket(t, cache)
+	testCacheExpire(t, cache)
+	testCacheMSave(t, cache)
+	testCacheGet(t, cache)
+
+	// 数据都保存在一个hash中
+	require.True(t, s.Exists("{test}"))
+	require.Equal(t, []string{"{test}", "{test}:ex"}, s.Keys())
+
+	// 删除bucket只需要删除hash和过期时间
+	require.NoError(t, cache.DelBucket("test"))
+	require.Empty(t, s.Keys())
+}
+
+func TestRedisHashCacheExpire(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := rredis.NewClient(&rredis.Options{Addr: s.Addr()})
+	db := redis_cache.NewRedisCache(client, redis_cache.WithKeyPrefix("app:"), redis_cache.WithHashBuckets("hash"))
+
+	q1 := zcache.Q("hash", zcache.QC().Args(1))
+	q2 := zcache.Q("hash", zcache.QC().Args(2))
+	q3 := zcache.Q("hash", zcache.QC().Args(3))
+	require.NoError(t, db.Set(q1, []byte("v1"), time.Millisecond*50))
+	require.NoError(t, db.Set(q2, []byte("v2"), time.Millisecond*50))
+	require.NoError(t, db.Set(q3, []byte("v3"), 0))
+	require.Equal(t, []string{"1", "2", "3"}, hashKeys(t, s, "app:{hash}"))
+
+	// 读取时检查过期时间, 过期的field会被删除
+	time.Sleep(time.Millisecond * 80)
+	_, err := db.Get(q1)
+	require.Equal(t, errs.CacheMiss, err)
+	require.Equal(t, []string{"2", "3"}, hashKeys(t, s, "app:{hash}"))
+
+	// 写入时清理过期的field
+	require.NoError(t, db.Set(zcache.Q("hash", zcache.QC().Args(4)), []byte("v4"), time.Hour))
+	require.Equal(t, []string{"3", "4"}, hashKeys(t, s, "app:{hash}"))
+	members, err := s.ZMembers("app:{hash}:ex")
+	require.NoError(t, err)
+	require.Equal(t, []string{"4"}, members)
+
+	// 永不过期的数据没有过期时间
+	bs, err := db.Get(q3)
+	require.NoError(t, err)
+	require.Equal(t, "v3", string(bs))
+}
+
+func TestRedisHashCacheMixed(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := rredis.NewClient(&rredis.Options{Addr: s.Addr()})
+	db := redis_cache.NewRedisCache(client, redis_cache.WithHashBuckets("hash"))
+
+	// 同时操作hash模式和普通模式的bucket
+	queries := []core.IQuery{
+		zcache.Q("hash", zcache.QC().Args(1)),
+		zcache.Q("string", zcache.QC().Args(1)),
+		zcache.Q("hash", zcache.QC().Args(2)),
+	}
+	items := make([]core.SetItem, len(queries))
+	for i, q := range queries {
+		items[i] = core.SetItem{Query: q, Data: []byte(q.Bucket() + q.ArgsText())}
+	}
+	for _, err := range cachedb.MSet(db, items) {
+		require.NoError(t, err)
+	}
+	require.True(t, s.Exists("string:1"))
+	require.Equal(t, []string{"1", "2"}, hashKeys(t, s, "{hash}"))
+
+	buffs, es := db.MGet(append(queries, zcache.Q("hash", zcache.QC().Args(3)))...)
+	require.Equal(t, [][]byte{[]byte("hash1"), []byte("string1"), []byte("hash2"), nil}, buffs)
+	require.Equal(t, []error{nil, nil, nil, errs.CacheMiss}, es)
+
+	require.NoError(t, db.Del(queries[0], queries[1]))
+	require.False(t, s.Exists("string:1"))
+	require.Equal(t, []string{"2"}, hashKeys(t, s, "{hash}"))
+}
+
+func hashKeys(t *testing.T, s *miniredis.Miniredis, key string) []string {
+	keys, err := s.HKeys(key)
+	require.NoError(t, err)
+	return keys
+}
+
+func TestRedisCacheTTL(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := rredis.NewClient(&rredis.Options{Addr: s.Addr()})
+	db := redis_cache.NewRedisCache(client, redis_cache.WithHashBuckets("hash")).(core.ITTLCacheDB)
+
+	queries := []core.IQuery{
+		zcache.Q("hash", zcache.QC().Args(1)),
+		zcache.Q("string", zcache.QC().Args(1)),
+		zcache.Q("hash", zcache.QC().Args(2)),
+		zcache.Q("string", zcache.QC().Args(2)),
+	}
+	items := []core.SetItem{
+		{Query: queries[0], Data: []byte("h1"), Expire: time.Minute},
+		{Query: queries[1], Data: []byte("s1"), Expire: time.Minute},
+		{Query: queries[2], Data: []byte("h2")},
+		{Query: queries[3], Data: []byte("s2")},
+	}
+	for _, err := range cachedb.MSet(db.(core.ICacheDB), items) {
+		require.NoError(t, err)
+	}
+
+	// 永不过期的数据剩余过期时间为0
+	buffs, ttls, es := db.MGetWithTTL(context.Background(), append(queries, zcache.Q("string", zcache.QC().Args(3)))...)
+	require.Equal(t, [][]byte{[]byte("h1"), []byte("s1"), []byte("h2"), []byte("s2"), nil}, buffs)
+	require.Equal(t, []error{nil, nil, nil, nil, errs.CacheMiss}, es)
+	for _, ttl := range ttls[:2] {
+		require.True(t, ttl > time.Second*50 && ttl <= time.Minute, ttl)
+	}
+	require.Equal(t, []time.Duration{0, 0, 0}, ttls[2:])
+
+	bs, ttl, err := db.GetWithTTL(context.Background(), queries[0])
+	require.NoError(t, err)
+	require.Equal(t, "h1", string(bs))
+	require.True(t, ttl > time.Second*50 && ttl <= time.Minute, ttl)
+}
+
+func TestGroupBy(t *testing.T) {
+	buckets := []string{"b", "a", "b", "c", "a"}
+	keys, groups := cachedb.GroupBy(len(buckets), func(i int) string { return buckets[i] })
+	require.Equal(t, []string{"b", "a", "c"}, keys)
+	require.Equal(t, [][]int{{0, 2}, {1, 4}, {3}}, groups)
+
+	keys, groups = cachedb.GroupBy(0, func(i int) string { return buckets[i] })
+	require.Empty(t, keys)
+	require.Empty(t, groups)
+}
@@ -0,0 +1,124 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	rredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/cachedb/memory-cache"
+	redis_cache "github.com/zlyuancn/zcache/cachedb/redis-cache"
+	redis_invalidator "github.com/zlyuancn/zcache/cachedb/redis-invalidator"
+	two_level_cache "github.com/zlyuancn/zcache/cachedb/two-level-cache"
+	"github.com/zlyuancn/zcache/codec"
+	"github.com/zlyuancn/zcache/core"
+)
+
+// 模拟一个实例, 本地缓存 + redis
+func makeInvalidatorPod(addr string, opts ...redis_invalidator.Option) (*zcache.Cache, core.ICacheDB) {
+	client := rredis.NewClient(&rredis.Options{Addr: addr})
+	local := memory_cache.NewMemoryCache()
+	inv := redis_invalidator.NewInvalidator(client, local, opts...)
+	db := two_level_cache.NewTwoLevelCache(inv.Wrap(redis_cache.NewRedisCache(client)),
+		two_level_cache.WithL1CacheDB(local),
+		two_level_cache.WithL1Expire(time.Hour),
+	)
+	return zcache.NewCache(zcache.WithCacheDB(db), zcache.WithCodec(codec.Byte)), local
+}
+
+func TestRedisInvalidator(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	podA, _ := makeInvalidatorPod(s.Addr())
+	defer podA.Close()
+	podB, localB := makeInvalidatorPod(s.Addr())
+	defer podB.Close()
+
+	const bucket = "test"
+	q := zcache.QC().Args(1)
+	require.NoError(t, podA.Save(bucket, "v1", 0, q))
+
+	// podB 读取后数据会进入本地缓存
+	var result string
+	require.NoError(t, podB.Query(bucket, &result, q))
+	require.Equal(t, "v1", result)
+	_, err := localB.Get(zcache.Q(bucket, q))
+	require.NoError(t, err)
+
+	// podA 修改数据后, podB 的本地缓存会失效
+	require.NoError(t, podA.Save(bucket, "v2", 0, q))
+	require.Eventually(t, func() bool {
+		_, err := localB.Get(zcache.Q(bucket, q))
+		return err != nil
+	}, time.Second, time.Millisecond*10)
+	require.NoError(t, podB.Query(bucket, &result, q))
+	require.Equal(t, "v2", result)
+
+	// 删除
+	require.NoError(t, podA.Del(bucket, q))
+	require.Eventually(t, func() bool {
+		_, err := localB.Get(zcache.Q(bucket, q))
+		return err != nil
+	}, time.Second, time.Millisecond*10)
+
+	// 删除bucket
+	require.NoError(t, podA.Save(bucket, "v3", 0, q))
+	require.NoError(t, podB.Query(bucket, &result, q))
+	require.NoError(t, podA.DelBucket(bucket))
+	require.Eventually(t, func() bool {
+		_, err := localB.Get(zcache.Q(bucket, q))
+		return err != nil
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestRedisInvalidatorReconnect(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	reconnected := make(chan struct{}, 1)
+	podA, _ := makeInvalidatorPod(s.Addr())
+	defer podA.Close()
+	podB, localB := makeInvalidatorPod(s.Addr(),
+		redis_invalidator.WithPingInterval(time.Millisecond*50),
+		redis_invalidator.WithReconnectInterval(time.Millisecond*50),
+		redis_invalidator.WithOnReconnect(func(local core.ICacheDB) {
+			reconnected <- struct{}{}
+		}),
+	)
+	defer podB.Close()
+
+	const bucket = "test"
+	q := zcache.QC().Args(1)
+	require.NoError(t, podA.Save(bucket, "v1", 0, q))
+
+	var result string
+	require.NoError(t, podB.Query(bucket, &result, q))
+
+	// 重启 redis, podB 会重新订阅
+	s.Close()
+	time.Sleep(time.Millisecond * 200)
+	require.NoError(t, s.Restart())
+
+	select {
+	case <-reconnected:
+	case <-time.After(time.Second * 3):
+		t.Fatal("not reconnected")
+	}
+
+	// 重连后仍然可以收到失效事件
+	require.NoError(t, podA.Save(bucket, "v2", 0, q))
+	require.Eventually(t, func() bool {
+		_, err := localB.Get(zcache.Q(bucket, q))
+		return err != nil
+	}, time.Second, time.Millisecond*10)
+}
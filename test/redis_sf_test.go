@@ -0,0 +1,251 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	rredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/core"
+	redis_sf "github.com/zlyuancn/zcache/single_flight/redis-sf"
+)
+
+// 创建多个使用同一个分布式单跑模块的实例, 每个实例有自己的本地缓存
+//
+// waiting 不为nil时, 实例第一次没有抢到一个锁时会向 waiting 发送一个信号, 表示这个实例开始等待其它实例的结果
+func makeRedisSfPods(t *testing.T, addr string, n int, waiting chan<- struct{}, loader func(query core.IQuery) (interface{}, error), opts ...redis_sf.Option) []*zcache.Cache {
+	pods := make([]*zcache.Cache, n)
+	for i := range pods {
+		client := rredis.NewClient(&rredis.Options{Addr: addr})
+		t.Cleanup(func() { _ = client.Close() })
+		if waiting != nil {
+			client.AddHook(&lockWaitHook{waiting: waiting, seen: make(map[string]bool)})
+		}
+		pods[i] = zcache.NewCache(zcache.WithSingleFlight(redis_sf.NewRedisSingleFlight(client, opts...)))
+		pods[i].RegisterLoaderFn("test", loader)
+	}
+	return pods
+}
+
+// 记录没有抢到锁的redis钩子, 每个锁只发送一次信号
+type lockWaitHook struct {
+	waiting chan<- struct{}
+	mx      sync.Mutex
+	seen    map[string]bool
+}
+
+func (h *lockWaitHook) BeforeProcess(ctx context.Context, cmd rredis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h *lockWaitHook) AfterProcess(ctx context.Context, cmd rredis.Cmder) error {
+	c, ok := cmd.(*rredis.BoolCmd)
+	if !ok || cmd.Name() != "set" || c.Err() != nil || c.Val() {
+		return nil
+	}
+	h.signal(cmd)
+	return nil
+}
+
+func (h *lockWaitHook) signal(cmd rredis.Cmder) {
+	key := fmt.Sprint(cmd.Args()[1])
+	h.mx.Lock()
+	first := !h.seen[key]
+	h.seen[key] = true
+	h.mx.Unlock()
+	if first {
+		h.waiting <- struct{}{}
+	}
+}
+
+func (h *lockWaitHook) BeforeProcessPipeline(ctx context.Context, cmds []rredis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h *lockWaitHook) AfterProcessPipeline(ctx context.Context, cmds []rredis.Cmder) error {
+	for _, cmd := range cmds {
+		_ = h.AfterProcess(ctx, cmd)
+	}
+	return nil
+}
+
+type podResult struct {
+	v   string
+	err error
+}
+
+// 所有实例同时查询同一个数据, 返回每个实例的结果
+func queryPods(pods []*zcache.Cache, args interface{}) []podResult {
+	results := make([]podResult, len(pods))
+	var wg sync.WaitGroup
+	for i, pod := range pods {
+		wg.Add(1)
+		go func(i int, pod *zcache.Cache) {
+			defer wg.Done()
+			results[i].err = pod.Query("test", &results[i].v, zcache.QC().Args(args))
+		}(i, pod)
+	}
+	wg.Wait()
+	return results
+}
+
+func TestRedisSingleFlight(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	// 加载器等到其它实例都在等待结果后才返回, 保证没有实例在结果写入后才开始查询
+	const podCount = 5
+	waiting := make(chan struct{}, podCount)
+	var loadCount int32
+	pods := makeRedisSfPods(t, s.Addr(), podCount, waiting, func(query core.IQuery) (interface{}, error) {
+		atomic.AddInt32(&loadCount, 1)
+		for i := 0; i < podCount-1; i++ {
+			<-waiting
+		}
+		if query.ArgsText() == "err" {
+			return nil, errors.New("load err")
+		}
+		return "v" + query.ArgsText(), nil
+	}, redis_sf.WithPollInterval(time.Millisecond*10))
+
+	// 所有实例同时查询一个没有缓存的数据, 只会加载一次
+	results := queryPods(pods, 1)
+	for _, r := range results {
+		require.NoError(t, r.err)
+		require.Equal(t, "v1", r.v)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&loadCount))
+	globalId := strconv.FormatUint(zcache.NewQuery("test", zcache.QC().Args(1)).GlobalId(), 10)
+	require.False(t, s.Exists("zcache:sf:lock:"+globalId))
+	resultKeys := 0
+	for _, key := range s.Keys() {
+		if strings.HasPrefix(key, "zcache:sf:result:"+globalId+":") {
+			resultKeys++
+		}
+	}
+	require.Equal(t, 1, resultKeys)
+
+	// 加载失败时等待的实例也会收到错误
+	atomic.StoreInt32(&loadCount, 0)
+	for _, r := range queryPods(pods, "err") {
+		require.EqualError(t, r.err, "load data error from loader: load err")
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&loadCount))
+}
+
+func TestRedisSingleFlightWaitTimeout(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	var loadCount int32
+	pods := makeRedisSfPods(t, s.Addr(), 2, nil, func(query core.IQuery) (interface{}, error) {
+		atomic.AddInt32(&loadCount, 1)
+		time.Sleep(time.Millisecond * 300)
+		return "v", nil
+	}, redis_sf.WithPollInterval(time.Millisecond*10), redis_sf.WithWaitTimeout(time.Millisecond*50))
+
+	// 等待超时后在本地加载
+	for _, r := range queryPods(pods, 1) {
+		require.NoError(t, r.err)
+	}
+	require.Equal(t, int32(2), atomic.LoadInt32(&loadCount))
+}
+
+func TestRedisSingleFlightFallback(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	pods := makeRedisSfPods(t, s.Addr(), 1, nil, func(query core.IQuery) (interface{}, error) {
+		return "v", nil
+	})
+	s.Close()
+
+	// redis 故障时在本地加载
+	var result string
+	require.NoError(t, pods[0].Query("test", &result, zcache.QC().Args(1)))
+	require.Equal(t, "v", result)
+}
+
+func TestRedisSingleFlightIgnoreOldResult(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := rredis.NewClient(&rredis.Options{Addr: s.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	sf := redis_sf.NewRedisSingleFlight(client, redis_sf.WithPollInterval(time.Millisecond*10))
+
+	// 上一次加载的结果还在, 其它实例持有锁时只会等待持有锁的实例的结果
+	query := zcache.NewQuery("test", zcache.QC().Args(1))
+	globalId := strconv.FormatUint(query.GlobalId(), 10)
+	require.NoError(t, s.Set("zcache:sf:result:"+globalId+":old", "\x00old"))
+	require.NoError(t, s.Set("zcache:sf:lock:"+globalId, "holder"))
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		_ = s.Set("zcache:sf:result:"+globalId+":holder", "\x00new")
+		s.Del("zcache:sf:lock:" + globalId)
+	}()
+
+	bs, err := sf.Do(query, func(query core.IQuery) ([]byte, error) {
+		return nil, errors.New("should not load")
+	})
+	require.NoError(t, err)
+	require.Equal(t, "new", string(bs))
+}
+
+func TestRedisSingleFlightBatch(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	// 批量加载器等到其它实例都在等待结果后才返回
+	const podCount = 3
+	waiting := make(chan struct{}, podCount*2)
+	var loadCount int32
+	pods := make([]*zcache.Cache, podCount)
+	for i := range pods {
+		client := rredis.NewClient(&rredis.Options{Addr: s.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+		client.AddHook(&lockWaitHook{waiting: waiting, seen: make(map[string]bool)})
+		pods[i] = zcache.NewCache(zcache.WithSingleFlight(redis_sf.NewRedisSingleFlight(client, redis_sf.WithPollInterval(time.Millisecond*10))))
+		pods[i].RegisterBatchLoaderFn("test", func(queries []zcache.IQuery) (map[int]interface{}, error) {
+			atomic.AddInt32(&loadCount, int32(len(queries)))
+			for i := 0; i < (podCount-1)*len(queries); i++ {
+				<-waiting
+			}
+			result := make(map[int]interface{}, len(queries))
+			for i, q := range queries {
+				result[i] = "v" + q.ArgsText()
+			}
+			return result, nil
+		})
+	}
+
+	// 所有实例同时批量查询, 每个数据只会加载一次
+	errs := make([]error, podCount)
+	values := make([][]string, podCount)
+	var wg sync.WaitGroup
+	for i, pod := range pods {
+		wg.Add(1)
+		go func(i int, pod *zcache.Cache) {
+			defer wg.Done()
+			errs[i] = pod.MQuery("test", &values[i], zcache.QC().Args(1), zcache.QC().Args(2))
+		}(i, pod)
+	}
+	wg.Wait()
+	for i := range pods {
+		require.NoError(t, errs[i])
+		require.Equal(t, []string{"v1", "v2"}, values[i])
+	}
+	require.Equal(t, int32(2), atomic.LoadInt32(&loadCount))
+}
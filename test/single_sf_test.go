@@ -0,0 +1,227 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/core"
+	single_sf "github.com/zlyuancn/zcache/single_flight/single-sf"
+)
+
+func TestSingleFlightShared(t *testing.T) {
+	sf := single_sf.NewSingleFlight()
+	query := zcache.NewQuery("test", zcache.QC().Args(1))
+
+	release := make(chan struct{})
+	results := make(chan string, 10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			bs, err := sf.Do(query, func(query core.IQuery) ([]byte, error) {
+				<-release
+				return []byte("v"), nil
+			})
+			if err != nil {
+				results <- err.Error()
+				return
+			}
+			results <- string(bs)
+		}()
+	}
+	waitFor(t, sf, 9)
+	close(release)
+	for i := 0; i < 10; i++ {
+		require.Equal(t, "v", <-results)
+	}
+
+	stats := sf.Stats()
+	require.Equal(t, uint64(1), stats.Leaders)
+	require.Equal(t, uint64(9), stats.Shared)
+}
+
+func TestSingleFlightPanic(t *testing.T) {
+	sf := single_sf.NewSingleFlight()
+	query := zcache.NewQuery("test", zcache.QC().Args(1))
+
+	// panic会转为错误返回给所有调用者
+	release := make(chan struct{})
+	es := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := sf.Do(query, func(query core.IQuery) ([]byte, error) {
+				<-release
+				panic("boom")
+			})
+			es <- err
+		}()
+	}
+	waitFor(t, sf, 4)
+	close(release)
+	for i := 0; i < 5; i++ {
+		require.EqualError(t, <-es, "single flight fn panic: boom")
+	}
+	require.Equal(t, uint64(1), sf.Stats().Panics)
+
+	// panic后不会阻塞之后的调用
+	bs, err := sf.Do(query, func(query core.IQuery) ([]byte, error) {
+		return []byte("v"), nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "v", string(bs))
+
+	// 批量执行
+	queries := []core.IQuery{query, zcache.NewQuery("test", zcache.QC().Args(2))}
+	_, batchErrs := sf.DoBatch(queries, func(queries []core.IQuery) ([][]byte, []error) {
+		panic("boom")
+	})
+	require.Len(t, batchErrs, 2)
+	require.EqualError(t, batchErrs[0], "single flight fn panic: boom")
+	require.EqualError(t, batchErrs[1], "single flight fn panic: boom")
+	bss, batchErrs := sf.DoBatch(queries, func(queries []core.IQuery) ([][]byte, []error) {
+		return [][]byte{[]byte("v1"), []byte("v2")}, make([]error, 2)
+	})
+	require.Equal(t, []error{nil, nil}, batchErrs)
+	require.Equal(t, "v2", string(bss[1]))
+}
+
+func TestSingleFlightAbandon(t *testing.T) {
+	sf := single_sf.NewSingleFlightWithOptions(single_sf.WithMaxWait(time.Millisecond * 50))
+	query := zcache.NewQuery("test", zcache.QC().Args(1))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	leaderDone := make(chan struct{})
+	go func() {
+		defer close(leaderDone)
+		_, _ = sf.Do(query, func(query core.IQuery) ([]byte, error) {
+			close(started)
+			<-release
+			return []byte("v"), nil
+		})
+	}()
+	<-started
+
+	// 超过最长等待时间
+	_, err := sf.Do(query, func(query core.IQuery) ([]byte, error) { return nil, nil })
+	require.Equal(t, single_sf.ErrWaitTimeout, err)
+
+	// ctx结束
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = sf.DoWithContext(ctx, query, func(ctx context.Context, query core.IQuery) ([]byte, error) { return nil, nil })
+	require.Equal(t, context.Canceled, err)
+	_, es := sf.DoBatchWithContext(ctx, []core.IQuery{query}, func(ctx context.Context, queries []core.IQuery) ([][]byte, []error) {
+		return make([][]byte, len(queries)), make([]error, len(queries))
+	})
+	require.Equal(t, []error{context.Canceled}, es)
+
+	close(release)
+	<-leaderDone
+
+	stats := sf.Stats()
+	require.Equal(t, uint64(1), stats.Leaders)
+	require.Equal(t, uint64(1), stats.Timeouts)
+	require.Equal(t, uint64(2), stats.Canceled)
+	require.Equal(t, uint64(0), stats.Shared)
+	require.Equal(t, uint64(0), stats.Waiting)
+}
+
+func TestSingleFlightLeaderCancel(t *testing.T) {
+	sf := single_sf.NewSingleFlightWithOptions(single_sf.WithMaxWait(time.Second))
+	query := zcache.NewQuery("test", zcache.QC().Args(1))
+
+	type ctxKey struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "v"))
+	started, release := make(chan struct{}), make(chan struct{})
+	fnErr := make(chan error, 1)
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := sf.DoWithContext(ctx, query, func(ctx context.Context, query core.IQuery) ([]byte, error) {
+			close(started)
+			<-release
+			fnErr <- ctx.Err()
+			return []byte(ctx.Value(ctxKey{}).(string)), nil
+		})
+		leaderErr <- err
+	}()
+	<-started
+
+	// 第一个调用者取消后立即返回, 还有调用者在等待时fn不会结束, 等待的调用者可以拿到结果
+	type result struct {
+		bs  []byte
+		err error
+	}
+	waiter := make(chan result, 1)
+	go func() {
+		bs, err := sf.Do(query, func(query core.IQuery) ([]byte, error) { return nil, nil })
+		waiter <- result{bs, err}
+	}()
+	waitFor(t, sf, 1)
+	cancel()
+	require.Equal(t, context.Canceled, <-leaderErr)
+	close(release)
+	require.NoError(t, <-fnErr)
+	r := <-waiter
+	require.NoError(t, r.err)
+	require.Equal(t, "v", string(r.bs))
+}
+
+func TestSingleFlightAllCancel(t *testing.T) {
+	sf := single_sf.NewSingleFlightWithOptions(single_sf.WithMaxWait(time.Second))
+	query := zcache.NewQuery("test", zcache.QC().Args(1))
+
+	// 所有调用者都放弃等待后fn的上下文结束, 错误为最后一个放弃的调用者的上下文的错误
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	fnErr := make(chan error, 1)
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := sf.DoWithContext(leaderCtx, query, func(ctx context.Context, query core.IQuery) ([]byte, error) {
+			close(started)
+			deadline, ok := ctx.Deadline()
+			if !ok || time.Until(deadline) > time.Second {
+				return nil, errors.New("fn context should have a deadline not later than the max wait")
+			}
+			<-ctx.Done()
+			fnErr <- ctx.Err()
+			return nil, ctx.Err()
+		})
+		leaderErr <- err
+	}()
+	<-started
+
+	waiterCtx, waiterCancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer waiterCancel()
+	waiterErr := make(chan error, 1)
+	go func() {
+		_, err := sf.DoWithContext(waiterCtx, query, func(ctx context.Context, query core.IQuery) ([]byte, error) { return nil, nil })
+		waiterErr <- err
+	}()
+	waitFor(t, sf, 1)
+	cancel()
+	require.Equal(t, context.Canceled, <-leaderErr)
+	require.Equal(t, context.DeadlineExceeded, <-waiterErr)
+	require.Equal(t, context.DeadlineExceeded, <-fnErr)
+
+	// 上下文结束后的调用会重新执行fn
+	bs, err := sf.Do(query, func(query core.IQuery) ([]byte, error) { return []byte("v"), nil })
+	require.NoError(t, err)
+	require.Equal(t, "v", string(bs))
+	require.Equal(t, uint64(2), sf.Stats().Leaders)
+}
+
+// 等待直到有 n 个调用者在等待结果
+func waitFor(t *testing.T, sf *single_sf.SingleFlight, n uint64) {
+	require.Eventually(t, func() bool { return sf.Stats().Waiting == n }, time.Second, time.Millisecond)
+}
@@ -0,0 +1,186 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/codec"
+	"github.com/zlyuancn/zcache/core"
+)
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	cache := makeMemoryCache()
+	const bucket = "test"
+
+	var count int32
+	cache.RegisterLoaderFn(bucket, func(query zcache.IQuery) (interface{}, error) {
+		n := atomic.AddInt32(&count, 1)
+		time.Sleep(time.Millisecond * 20)
+		return string(rune('0' + n)), nil
+	},
+		zcache.WithLoaderExpire(time.Millisecond*100),
+		zcache.WithLoaderStaleWindow(time.Millisecond*300),
+	)
+
+	var result string
+	require.NoError(t, cache.Query(bucket, &result))
+	require.Equal(t, "1", result)
+
+	// 软过期后立即返回旧数据, 并在后台刷新
+	time.Sleep(time.Millisecond * 150)
+	start := time.Now()
+	require.NoError(t, cache.Query(bucket, &result))
+	require.Equal(t, "1", result)
+	require.Less(t, int64(time.Since(start)), int64(time.Millisecond*20))
+
+	// 多次读取只会触发一次刷新
+	require.NoError(t, cache.Query(bucket, &result))
+	require.Eventually(t, func() bool {
+		_ = cache.Query(bucket, &result)
+		return result == "2"
+	}, time.Second, time.Millisecond*5)
+	require.Equal(t, int32(2), atomic.LoadInt32(&count))
+
+	// 超过软过期窗口后阻塞等待加载
+	time.Sleep(time.Millisecond * 450)
+	require.NoError(t, cache.Query(bucket, &result))
+	require.Equal(t, "3", result)
+	require.Equal(t, int32(3), atomic.LoadInt32(&count))
+}
+
+func TestGracePeriod(t *testing.T) {
+	var staleCount int32
+	cache := zcache.NewCache(
+		zcache.WithCodec(codec.Byte),
+		zcache.WithGracePeriod(time.Second),
+		zcache.WithOnServeStale(func(query zcache.IQuery, loadErr error) {
+			atomic.AddInt32(&staleCount, 1)
+		}),
+	)
+	const bucket = "test"
+
+	var mode int32 // 0 正常, 1 返回错误, 2 panic
+	cache.RegisterLoaderFn(bucket, func(query zcache.IQuery) (interface{}, error) {
+		switch atomic.LoadInt32(&mode) {
+		case 1:
+			return nil, errors.New("db is down")
+		case 2:
+			panic("db is down")
+		}
+		return "v" + query.ArgsText(), nil
+	}, zcache.WithLoaderExpire(time.Millisecond*50))
+
+	qc := zcache.QC().Args(1)
+	var result string
+	require.NoError(t, cache.Query(bucket, &result, qc))
+	require.Equal(t, "v1", result)
+	require.False(t, qc.IsStale())
+
+	var results []string
+	require.NoError(t, cache.MQuery(bucket, &results, zcache.QC().Args(2), zcache.QC().Args(3)))
+
+	// 数据过期后加载失败, 返回过期数据
+	time.Sleep(time.Millisecond * 80)
+	atomic.StoreInt32(&mode, 1)
+	require.NoError(t, cache.Query(bucket, &result, qc))
+	require.Equal(t, "v1", result)
+	require.True(t, qc.IsStale())
+
+	atomic.StoreInt32(&mode, 2)
+	qcs := []*zcache.QueryConfig{zcache.QC().Args(2), zcache.QC().Args(4)}
+	results = nil
+	err := cache.MQuery(bucket, &results, qcs...)
+	require.Error(t, err)
+	require.Equal(t, "v2", results[0])
+	require.True(t, qcs[0].IsStale())
+	require.NoError(t, qcs[0].GetErr())
+	require.False(t, qcs[1].IsStale())
+	require.Error(t, qcs[1].GetErr())
+
+	require.Equal(t, int32(2), atomic.LoadInt32(&staleCount))
+
+	// 加载恢复后返回新数据
+	atomic.StoreInt32(&mode, 0)
+	require.NoError(t, cache.Query(bucket, &result, qc))
+	require.Equal(t, "v1", result)
+	require.False(t, qc.IsStale())
+
+	// 重复使用的query在加载成功后不再是过期数据
+	q := zcache.Q(bucket, zcache.QC().Args(5))
+	require.NoError(t, cache.Get(q, &result))
+	time.Sleep(time.Millisecond * 80)
+	atomic.StoreInt32(&mode, 1)
+	require.NoError(t, cache.Get(q, &result))
+	require.True(t, q.(core.IStaleQuery).IsStale())
+	atomic.StoreInt32(&mode, 0)
+	require.NoError(t, cache.Get(q, &result))
+	require.Equal(t, "v5", result)
+	require.False(t, q.(core.IStaleQuery).IsStale())
+
+	// 没有实现 core.IStaleQuery 的query也可以使用过期数据
+	plain := plainQuery{zcache.Q(bucket, zcache.QC().Args(6))}
+	require.NoError(t, cache.Get(plain, &result))
+	time.Sleep(time.Millisecond * 80)
+	atomic.StoreInt32(&mode, 1)
+	require.NoError(t, cache.Get(plain, &result))
+	require.Equal(t, "v6", result)
+	atomic.StoreInt32(&mode, 0)
+}
+
+// 只实现了 core.IQuery 的query
+type plainQuery struct {
+	core.IQuery
+}
+
+func TestStaleRefreshReusedQuery(t *testing.T) {
+	loaded := make(chan struct{}, 1)
+	cache := zcache.NewCache(zcache.WithInterceptors(func(ctx context.Context, inv *core.Invocation, next core.Handler) error {
+		err := next(ctx, inv)
+		if inv.Op == core.OpLoad {
+			if err != nil {
+				inv.Queries[0].SetError(err)
+			}
+			loaded <- struct{}{}
+		}
+		return err
+	}))
+	const bucket = "test"
+
+	var fail int32
+	cache.RegisterLoaderFn(bucket, func(query zcache.IQuery) (interface{}, error) {
+		if atomic.LoadInt32(&fail) == 1 {
+			return nil, errors.New("db is down")
+		}
+		return "v", nil
+	},
+		zcache.WithLoaderExpire(time.Millisecond*50),
+		zcache.WithLoaderStaleWindow(time.Second),
+	)
+
+	q := zcache.Q(bucket)
+	var result string
+	require.NoError(t, cache.Get(q, &result))
+	<-loaded
+
+	// 后台刷新失败不会修改调用者的query
+	time.Sleep(time.Millisecond * 80)
+	atomic.StoreInt32(&fail, 1)
+	require.NoError(t, cache.Get(q, &result))
+	require.Equal(t, "v", result)
+	<-loaded
+	require.NoError(t, q.Err())
+}
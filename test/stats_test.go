@@ -0,0 +1,95 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/core"
+	memory_stats "github.com/zlyuancn/zcache/stats/memory-stats"
+)
+
+func TestMemoryStats(t *testing.T) {
+	s := memory_stats.NewMemoryStats()
+	cache := zcache.NewCache(zcache.WithStats(s))
+	cache.RegisterLoaderFn("test", func(query core.IQuery) (interface{}, error) {
+		if query.ArgsText() == "err" {
+			return nil, errors.New("err")
+		}
+		time.Sleep(time.Millisecond * 2)
+		return query.ArgsText(), nil
+	})
+
+	var result string
+	require.NoError(t, cache.Query("test", &result, zcache.QC().Args(1)))
+	require.NoError(t, cache.Query("test", &result, zcache.QC().Args(1)))
+	require.Error(t, cache.Query("test", &result, zcache.QC().Args("err")))
+
+	// 解码错误
+	var n int
+	require.Error(t, cache.Query("test", &n, zcache.QC().Args(1)))
+
+	// 批量获取
+	var results []string
+	require.NoError(t, cache.MQuery("test", &results, zcache.QC().Args(1), zcache.QC().Args(2)))
+
+	st := s.BucketStats("test")
+	require.Equal(t, uint64(3), st.Hits)
+	require.Equal(t, uint64(3), st.Misses)
+	require.Equal(t, uint64(3), st.LoaderCalls)
+	require.Equal(t, uint64(1), st.LoaderErrors)
+	require.Equal(t, uint64(1), st.DecodeErrors)
+	require.Equal(t, uint64(0), st.CacheErrors)
+	require.Equal(t, 0.5, st.HitRatio())
+	require.True(t, st.AvgLoaderLatency() > 0)
+	require.Equal(t, st, s.Stats()["test"])
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, s.WritePrometheus(buf))
+	text := buf.String()
+	require.Contains(t, text, "# TYPE zcache_hits_total counter\n")
+	require.Contains(t, text, "zcache_hits_total{bucket=\"test\"} 3\n")
+	require.Contains(t, text, "zcache_loader_errors_total{bucket=\"test\"} 1\n")
+	require.Contains(t, text, "zcache_loader_duration_seconds_bucket{bucket=\"test\",le=\"+Inf\"} 3\n")
+	require.Contains(t, text, "zcache_loader_duration_seconds_count{bucket=\"test\"} 3\n")
+}
+
+func TestMemoryStatsSharedWait(t *testing.T) {
+	s := memory_stats.NewMemoryStats()
+	cache := zcache.NewCache(zcache.WithStats(s))
+
+	release := make(chan struct{})
+	cache.RegisterLoaderFn("test", func(query core.IQuery) (interface{}, error) {
+		<-release
+		return "v", nil
+	})
+
+	es := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			var result string
+			es <- cache.Query("test", &result, zcache.QC().Args(1))
+		}()
+	}
+	time.Sleep(time.Millisecond * 50)
+	close(release)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, <-es)
+	}
+
+	st := s.BucketStats("test")
+	require.Equal(t, uint64(1), st.LoaderCalls)
+	require.Equal(t, uint64(4), st.SharedWaits)
+}
@@ -0,0 +1,152 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/core"
+	otel_tracer "github.com/zlyuancn/zcache/tracer/otel-tracer"
+)
+
+type recordSpan struct {
+	name   string
+	parent *recordSpan
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+func (s *recordSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *recordSpan) RecordError(err error) {
+	if err != nil {
+		s.err = err
+	}
+}
+func (s *recordSpan) End() { s.ended = true }
+
+type spanCtxKey struct{}
+
+// 在内存中记录span
+type recordTracer struct {
+	mx    sync.Mutex
+	spans []*recordSpan
+}
+
+func (t *recordTracer) Start(ctx context.Context, name string) (context.Context, core.ISpan) {
+	parent, _ := ctx.Value(spanCtxKey{}).(*recordSpan)
+	span := &recordSpan{name: name, parent: parent, attrs: make(map[string]interface{})}
+	t.mx.Lock()
+	t.spans = append(t.spans, span)
+	t.mx.Unlock()
+	return context.WithValue(ctx, spanCtxKey{}, span), span
+}
+
+func (t *recordTracer) take() []*recordSpan {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	spans := t.spans
+	t.spans = nil
+	return spans
+}
+
+func spanNames(spans []*recordSpan) []string {
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.name
+	}
+	return names
+}
+
+func TestTracer(t *testing.T) {
+	tracer := new(recordTracer)
+	cache := zcache.NewCache(zcache.WithTracer(tracer))
+	cache.RegisterLoaderFn("test", func(query core.IQuery) (interface{}, error) {
+		if query.ArgsText() == "err" {
+			return nil, errors.New("load err")
+		}
+		return "v", nil
+	})
+
+	// 未命中
+	var result string
+	require.NoError(t, cache.Query("test", &result, zcache.QC().Args(1)))
+	spans := tracer.take()
+	require.Equal(t, []string{core.SpanCacheGet, core.SpanSingleFlight, core.SpanLoad, core.SpanEncode, core.SpanCacheSet, core.SpanDecode}, spanNames(spans))
+	for _, s := range spans {
+		require.True(t, s.ended)
+		require.Equal(t, "test", s.attrs[core.AttrBucket])
+	}
+	require.Equal(t, false, spans[0].attrs[core.AttrHit])
+	require.Equal(t, "1", spans[0].attrs[core.AttrArgs])
+	require.Equal(t, 0, spans[1].attrs[core.AttrShared])
+	require.Equal(t, spans[1], spans[2].parent) // 加载器在单跑模块的span中
+	require.Equal(t, spans[1], spans[4].parent)
+
+	// 命中
+	require.NoError(t, cache.Query("test", &result, zcache.QC().Args(1)))
+	spans = tracer.take()
+	require.Equal(t, []string{core.SpanCacheGet, core.SpanDecode}, spanNames(spans))
+	require.Equal(t, true, spans[0].attrs[core.AttrHit])
+
+	// 加载错误
+	require.Error(t, cache.Query("test", &result, zcache.QC().Args("err")))
+	spans = tracer.take()
+	require.Equal(t, []string{core.SpanCacheGet, core.SpanSingleFlight, core.SpanLoad}, spanNames(spans))
+	require.EqualError(t, spans[2].err, "load err")
+	require.Error(t, spans[1].err)
+
+	// 批量获取
+	var results []string
+	require.NoError(t, cache.MQuery("test", &results, zcache.QC().Args(1), zcache.QC().Args(2)))
+	spans = tracer.take()
+	require.Equal(t, core.SpanCacheMGet, spans[0].name)
+	require.Equal(t, 2, spans[0].attrs[core.AttrCount])
+	require.Equal(t, 1, spans[0].attrs[core.AttrHits])
+}
+
+func TestOtelTracer(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	cache := zcache.NewCache(zcache.WithTracer(otel_tracer.NewOtelTracer(provider.Tracer("test"))))
+	cache.RegisterLoaderFn("test", func(query core.IQuery) (interface{}, error) {
+		return nil, errors.New("load err")
+	})
+
+	var result string
+	require.Error(t, cache.Query("test", &result, zcache.QC().Args(1)))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 3)
+	byName := make(map[string]sdktrace.ReadOnlySpan, len(spans))
+	for _, s := range spans {
+		byName[s.Name()] = s
+	}
+
+	get := byName[core.SpanCacheGet]
+	require.NotNil(t, get)
+	require.Contains(t, get.Attributes(), attribute.String(core.AttrBucket, "test"))
+	require.Contains(t, get.Attributes(), attribute.Bool(core.AttrHit, false))
+	require.Equal(t, codes.Unset, get.Status().Code)
+
+	load := byName[core.SpanLoad]
+	require.NotNil(t, load)
+	require.Equal(t, codes.Error, load.Status().Code)
+	require.Equal(t, byName[core.SpanSingleFlight].SpanContext().SpanID(), load.Parent().SpanID())
+}
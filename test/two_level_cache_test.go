@@ -0,0 +1,136 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zlyuancn/zcache"
+	"github.com/zlyuancn/zcache/cachedb/memory-cache"
+	two_level_cache "github.com/zlyuancn/zcache/cachedb/two-level-cache"
+	"github.com/zlyuancn/zcache/core"
+	"github.com/zlyuancn/zcache/errs"
+)
+
+// 记录调用次数的缓存数据库
+type countCacheDB struct {
+	core.ICacheDB
+	getCount, mgetCount int
+	mgetSize            int
+}
+
+func (c *countCacheDB) Get(query core.IQuery) ([]byte, error) {
+	c.getCount++
+	return c.ICacheDB.Get(query)
+}
+
+func (c *countCacheDB) MGet(queries ...core.IQuery) ([][]byte, []error) {
+	c.mgetCount++
+	c.mgetSize += len(queries)
+	return c.ICacheDB.MGet(queries...)
+}
+
+func TestTwoLevelCachePromote(t *testing.T) {
+	l1 := memory_cache.NewMemoryCache()
+	l2 := &countCacheDB{ICacheDB: memory_cache.NewMemoryCache()}
+	db := two_level_cache.NewTwoLevelCache(l2, two_level_cache.WithL1CacheDB(l1), two_level_cache.WithL1Expire(time.Minute))
+
+	q := zcache.Q("test", zcache.QC().Args(1))
+	require.NoError(t, l2.Set(q, []byte("v1"), 0))
+
+	// 一级缓存未命中, 从二级缓存读取并提升
+	bs, err := db.Get(q)
+	require.NoError(t, err)
+	require.Equal(t, "v1", string(bs))
+	require.Equal(t, 1, l2.getCount)
+
+	bs, err = l1.Get(q)
+	require.NoError(t, err)
+	require.Equal(t, "v1", string(bs))
+
+	// 命中一级缓存不会访问二级缓存
+	_, err = db.Get(q)
+	require.NoError(t, err)
+	require.Equal(t, 1, l2.getCount)
+
+	// 删除同时作用于两级缓存
+	require.NoError(t, db.Del(q))
+	_, err = l1.Get(q)
+	require.Equal(t, errs.CacheMiss, err)
+	_, err = l2.ICacheDB.Get(q)
+	require.Equal(t, errs.CacheMiss, err)
+}
+
+func TestTwoLevelCacheMGet(t *testing.T) {
+	l1 := memory_cache.NewMemoryCache()
+	l2 := &countCacheDB{ICacheDB: memory_cache.NewMemoryCache()}
+	db := two_level_cache.NewTwoLevelCache(l2, two_level_cache.WithL1CacheDB(l1))
+
+	queries := make([]core.IQuery, 5)
+	for i := range queries {
+		queries[i] = zcache.Q("test", zcache.QC().Args(i))
+	}
+	require.NoError(t, db.Set(queries[0], []byte("v0"), 0))
+	require.NoError(t, db.Set(queries[1], []byte("v1"), 0))
+	require.NoError(t, l2.Set(queries[2], []byte("v2"), 0))
+
+	buffs, es := db.MGet(queries...)
+	require.Equal(t, []string{"v0", "v1", "v2"}, []string{string(buffs[0]), string(buffs[1]), string(buffs[2])})
+	require.NoError(t, es[0])
+	require.NoError(t, es[1])
+	require.NoError(t, es[2])
+	require.Equal(t, errs.CacheMiss, es[3])
+	require.Equal(t, errs.CacheMiss, es[4])
+
+	// 只有一级缓存未命中的请求会批量访问二级缓存
+	require.Equal(t, 1, l2.mgetCount)
+	require.Equal(t, 3, l2.mgetSize)
+
+	bs, err := l1.Get(queries[2])
+	require.NoError(t, err)
+	require.Equal(t, "v2", string(bs))
+
+	require.NoError(t, db.DelBucket("test"))
+	_, es = db.MGet(queries...)
+	for _, err := range es {
+		require.Equal(t, errs.CacheMiss, err)
+	}
+}
+
+func TestTwoLevelCachePromoteTTL(t *testing.T) {
+	l1 := memory_cache.NewMemoryCache()
+	l2 := memory_cache.NewMemoryCache()
+	db := two_level_cache.NewTwoLevelCache(l2, two_level_cache.WithL1CacheDB(l1), two_level_cache.WithL1Expire(time.Minute))
+
+	queries := []core.IQuery{zcache.Q("test", zcache.QC().Args(1)), zcache.Q("test", zcache.QC().Args(2))}
+	require.NoError(t, l2.Set(queries[0], []byte("v1"), time.Millisecond*100))
+	require.NoError(t, l2.Set(queries[1], []byte("v2"), time.Millisecond*100))
+
+	// 提升到一级缓存的数据使用二级缓存中的剩余过期时间
+	_, err := db.Get(queries[0])
+	require.NoError(t, err)
+	_, es := db.MGet(queries[1])
+	require.NoError(t, es[0])
+	_, err = l1.Get(queries[0])
+	require.NoError(t, err)
+	_, err = l1.Get(queries[1])
+	require.NoError(t, err)
+
+	// 二级缓存中的数据过期后一级缓存中的数据也过期了
+	time.Sleep(time.Millisecond * 150)
+	for _, q := range queries {
+		_, err = l1.Get(q)
+		require.Equal(t, errs.CacheMiss, err)
+		_, err = db.Get(q)
+		require.Equal(t, errs.CacheMiss, err)
+	}
+}
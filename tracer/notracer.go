@@ -0,0 +1,34 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package tracer
+
+import (
+	"context"
+
+	"github.com/zlyuancn/zcache/core"
+)
+
+var _ core.ITracer = (*noTracer)(nil)
+var _ core.ISpan = (*noSpan)(nil)
+
+type noTracer struct{}
+type noSpan struct{}
+
+var defaultNoSpan = new(noSpan)
+
+// 一个不做任何追踪的ITracer
+func NoTracer() core.ITracer { return new(noTracer) }
+
+func (*noTracer) Start(ctx context.Context, _ string) (context.Context, core.ISpan) {
+	return ctx, defaultNoSpan
+}
+
+func (*noSpan) SetAttribute(string, interface{}) {}
+func (*noSpan) RecordError(error)                {}
+func (*noSpan) End()                             {}
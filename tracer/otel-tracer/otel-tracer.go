@@ -0,0 +1,83 @@
+/*
+-------------------------------------------------
+   Author :       zlyuancn
+   date：         2026/10/18
+   Description :
+-------------------------------------------------
+*/
+
+package otel_tracer
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/zlyuancn/zcache/core"
+)
+
+// 默认的 tracer 名
+const DefaultTracerName = "github.com/zlyuancn/zcache"
+
+var _ core.ITracer = (*otelTracer)(nil)
+var _ core.ISpan = (*otelSpan)(nil)
+
+type otelTracer struct {
+	tracer trace.Tracer
+}
+
+// 创建一个基于 OpenTelemetry 的 ITracer, tracer 为 nil 时使用全局的 TracerProvider
+func NewOtelTracer(tracer trace.Tracer) core.ITracer {
+	if tracer == nil {
+		tracer = otel.Tracer(DefaultTracerName)
+	}
+	return &otelTracer{tracer: tracer}
+}
+
+func (t *otelTracer) Start(ctx context.Context, name string) (context.Context, core.ISpan) {
+	ctx, span := t.tracer.Start(ctx, name)
+	return ctx, &otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s *otelSpan) SetAttribute(key string, value interface{}) {
+	s.span.SetAttributes(makeAttribute(key, value))
+}
+
+// 将属性值转为 attribute.KeyValue, 不支持的类型转为字符串
+func makeAttribute(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case fmt.Stringer:
+		return attribute.Stringer(key, v)
+	}
+	return attribute.String(key, fmt.Sprint(value))
+}
+
+func (s *otelSpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *otelSpan) End() {
+	s.span.End()
+}